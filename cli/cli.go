@@ -5,25 +5,100 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"regexp"
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/handlename/ssmwrap/v2"
 	"github.com/handlename/ssmwrap/v2/internal/app"
 	"github.com/handlename/ssmwrap/v2/internal/cli"
+	"github.com/samber/lo"
 )
 
 type ExitStatus int
 
+// Exit statuses of ssmwrap.
+// In supervisor mode, exit status of the command is passed through as is.
 const (
-	ExitStatusOK    ExitStatus = 0
+	// ExitStatusOK means success.
+	ExitStatusOK ExitStatus = 0
+
+	// ExitStatusError means an error not classified below.
 	ExitStatusError ExitStatus = 1
+
+	// ExitStatusInvalidArguments means invalid flags or arguments.
+	ExitStatusInvalidArguments ExitStatus = 2
+
+	// ExitStatusAWSConfigError means failure of loading AWS configuration or credentials.
+	ExitStatusAWSConfigError ExitStatus = 3
+
+	// ExitStatusFetchError means failure of fetching parameters from SSM.
+	ExitStatusFetchError ExitStatus = 4
+
+	// ExitStatusParameterNotFound means a parameter of a rule with `required=true` does not exist.
+	ExitStatusParameterNotFound ExitStatus = 5
+
+	// ExitStatusDestinationError means failure of exporting parameters to destinations.
+	ExitStatusDestinationError ExitStatus = 6
+
+	// ExitStatusCommandNotExecutable means the command exists but can not be executed.
+	ExitStatusCommandNotExecutable ExitStatus = 126
+
+	// ExitStatusCommandNotFound means the command is not found.
+	ExitStatusCommandNotFound ExitStatus = 127
 )
 
+// exitStatusOf returns exit status for err.
+func exitStatusOf(err error) ExitStatus {
+	if err == nil {
+		return ExitStatusOK
+	}
+
+	var (
+		exitErr        *app.ExitError
+		awsConfigErr   *app.AWSConfigError
+		fetchErr       *app.FetchError
+		notFoundErr    *app.ParameterNotFoundError
+		destinationErr *app.DestinationError
+		commandErr     *app.CommandError
+	)
+
+	switch {
+	case errors.As(err, &exitErr):
+		return ExitStatus(exitErr.Code)
+	case errors.As(err, &awsConfigErr):
+		return ExitStatusAWSConfigError
+	case errors.As(err, &fetchErr):
+		return ExitStatusFetchError
+	case errors.As(err, &notFoundErr):
+		return ExitStatusParameterNotFound
+	case errors.As(err, &destinationErr):
+		return ExitStatusDestinationError
+	case errors.As(err, &commandErr):
+		if commandErr.NotFound() {
+			return ExitStatusCommandNotFound
+		}
+
+		return ExitStatusCommandNotExecutable
+	default:
+		return ExitStatusError
+	}
+}
+
+// printError prints err to stderr.
+func printError(err error) {
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "Interrupted\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "Error occurred: %s\n", err)
+	}
+}
+
 func flagViaEnv(prefix string, multiple bool) []string {
 	if multiple {
 		values := []string{}
@@ -50,12 +125,34 @@ func flagViaEnv(prefix string, multiple bool) []string {
 		return values
 	}
 
-	return []string{os.Getenv(prefix)}
+	// keep default value of the flag if the variable is not set
+	if v := os.Getenv(prefix); v != "" {
+		return []string{v}
+	}
+
+	return []string{}
 }
 
 type Flags struct {
 	VersionFlag bool
 	Retries     int
+	Supervise   bool
+
+	Timeout        time.Duration
+	RequestTimeout time.Duration
+
+	RefreshInterval time.Duration
+	ReloadSignal    string
+	StopSignal      string
+	StopTimeout     time.Duration
+	Restart         string
+	MaxRestarts     int
+	RestartBackoff  time.Duration
+	CleanupFiles    bool
+	CleanEnv        bool
+	KeepEnv         cli.PatternFlags
+
+	Shell string
 
 	RuleFlags cli.RuleFlags
 	EnvFlags  cli.EnvFlags
@@ -69,9 +166,23 @@ func parseFlags(args []string, flagEnvPrefix string) (*Flags, []string, error) {
 
 	fs.BoolVar(&flags.VersionFlag, "version", false, "Display version and exit")
 	fs.IntVar(&flags.Retries, "retries", 0, "Number of times of retry. Default is 0")
+	fs.DurationVar(&flags.Timeout, "timeout", 0, "Timeout for whole of fetching parameters (e.g. 30s). Default is no timeout.")
+	fs.DurationVar(&flags.RequestTimeout, "request-timeout", 0, "Timeout for each request to SSM (e.g. 5s). Default is no timeout.")
+	fs.BoolVar(&flags.Supervise, "supervise", false, "Run command as a child process instead of replacing ssmwrap process. Signals are forwarded to the command, and ssmwrap exits with the command's exit status.")
+	fs.DurationVar(&flags.RefreshInterval, "refresh-interval", 0, "Interval to refresh parameters (e.g. 5m). If any parameter is changed, the command will be restarted. Implies -supervise.")
+	fs.StringVar(&flags.StopSignal, "stop-signal", "", "Name of `signal` sent to the command when ssmwrap receives SIGTERM or restarts the command in supervisor mode. Default is TERM.")
+	fs.DurationVar(&flags.StopTimeout, "stop-timeout", app.DefaultStopTimeout, "Time to wait for the command to exit after the stop signal is sent. After the timeout, the command is killed by SIGKILL. If 0, ssmwrap waits forever and never kills the command.")
+	fs.StringVar(&flags.Restart, "restart", "", "Restart `policy` of the command in supervisor mode. `never`, `on-failure` or `always`. Parameters are fetched again before restarting. Default is never. Implies -supervise unless never.")
+	fs.IntVar(&flags.MaxRestarts, "max-restarts", 0, "Max number of restarts by -restart policy. Default is 0 (unlimited).")
+	fs.DurationVar(&flags.RestartBackoff, "restart-backoff", 0, "Delay before the first restart by -restart policy. The delay is doubled on each restart up to 5m. Default is 1s.")
+	fs.StringVar(&flags.ReloadSignal, "reload-signal", "", "Name of `signal` sent to the command (e.g. HUP) instead of restarting it, when only parameters for file rules are changed on refresh.")
+	fs.BoolVar(&flags.CleanupFiles, "cleanup-files", false, "Remove all exported files when the command exits. Files existed before are restored. Implies -supervise.")
+	fs.BoolVar(&flags.CleanEnv, "clean-env", false, "Pass only exported parameters and variables allowed by -keep-env to the command.")
+	fs.Var(&flags.KeepEnv, "keep-env", "Glob `pattern` of environment variable names passed to the command (e.g. 'AWS_*'). Multiple flags are allowed.\nVariables to configure ssmwrap ("+flagEnvPrefix+"*) are not passed to the command unless allowed by this flag.")
+	fs.StringVar(&flags.Shell, "shell", "", "Kind of `shell` to evaluate output of shell-env subcommand. `bash`, `zsh`, `fish` or `powershell`. Default is bash.")
 	fs.Var(&flags.RuleFlags, "rule", strings.Join([]string{
 		"Set rule for exporting values. multiple flags are allowed.",
-		"format: path=...,type={env,file}[,to=...][,entirepath={true,false}][,prefix=...][,mode=...][,gid=...][,uid=...]",
+		"format: path=...,type={env,file,bundle,template,dir}[,to=...][,format=...][,src=...][,entirepath={true,false}][,prefix=...][,expand=json][,expandsep=...][,join={true,false}][,joinsep=...][,joinorder={name,lastmodified}][,mode=...][,gid=...][,uid=...][,cleanup={true,false}][,followsymlink={true,false}][,decode=...][,key=...][,keyoptional={true,false}][,list={index,join}][,listsep=...][,mkdir={true,false}][,dirmode=...][,diruid=...][,dirgid=...][,required={true,false}]",
 		"parameters:",
 		"        path: [required]",
 		"              Path of parameter store.",
@@ -79,24 +190,76 @@ func parseFlags(args []string, flagEnvPrefix string) (*Flags, []string, error) {
 		"              If `path` ends with `/**/*`, all values under the path will be exported.",
 		"              If `path` ends with `/*`, only top level values under the path will be exported.",
 		"        type: [required]",
-		"              Destination type. `env` or `file`.",
-		"          to: [required for `type=file`]",
+		"              Destination type. `env`, `file`, `bundle`, `template` or `dir`.",
+		"              If `type=bundle`, all values are written into one file.",
+		"              If `type=template`, a file rendered from template with values is written.",
+		"              If `type=dir`, each value is written into its own file under a directory.",
+		"          to: [required except for `type=env`]",
 		"              Destination path.",
 		"              If `type=env`, `to` is name of exported environment variable.",
+		"              If `type=env` and `path` ends with `/*` or `/**/*`, `to` is a template of names with placeholders.",
+		"              `{path}` is replaced with sub-path of parameter under `path`, and `{name}` with the last element of it. (e.g. `to=APP_{name}`)",
 		"              If `type=env`, but `to` is not set, `path` will be used as name of exported environment variable.",
-		"              If `type=file`, `to` is path of file to write.",
-		"  entirepath: [optional, only for `type=env`]",
+		"              If `type=file`, `type=bundle` or `type=template`, `to` is path of file to write.",
+		"              If `type=dir`, `to` is path of directory. Sub-path of parameter under `path` becomes relative path of file.",
+		"      format: [required for `type=bundle`]",
+		"              Format of file. `dotenv`, `json`, `yaml`, `toml` or `properties`.",
+		"              Keys are named as same as environment variables by `type=env`.",
+		"              In `json`, `yaml` and `toml`, nested paths become nested objects.",
+		"         src: [required for `type=template`]",
+		"              Path of Go text/template file.",
+		"              Values under `path` are available by functions like `param \"/path/to/param\"`.",
+		"  entirepath: [optional, only for `type=env` and `type=bundle`]",
 		"              Export entire path as environment variables name.",
 		"              If `entirepath=true`, all values under the path will be exported. (/path/to/param -> PATH_TO_PARAM)",
 		"              If `entirepath=false`, only top level values under the path will be exported. (/path/to/param -> PARAM)",
-		"      prefix: [optional, only for `type=env`]",
+		"      prefix: [optional, only for `type=env` and `type=bundle`]",
 		"              Prefix for exported environment variable.",
-		"        mode: [optional, only for `type=file`]",
+		"      expand: [optional, only for `type=env`]",
+		"              Expand each value into many environment variables. Only `json` is supported.",
+		"              Each key of JSON object is appended to name of variable. (/path/to/db {\"user\":\"app\"} -> DB_USER)",
+		"   expandsep: [optional, only for `type=env` with `expand`]",
+		"              Separator of names of expanded variables, also used for nested objects. Default is `_`.",
+		"        join: [optional, only for `type=env`]",
+		"              Join values of all parameters under `path` into one environment variable named by `to`.",
+		"     joinsep: [optional, only for `type=env` with `join`]",
+		"              Separator of joined values. Escape sequences are allowed as same as `listsep`. Default is a space.",
+		"   joinorder: [optional, only for `type=env` with `join`]",
+		"              Order of joined values. `name` (default) or `lastmodified` (oldest first).",
+		"        mode: [optional, only for types writing files]",
 		"              File mode. Default is 0644.",
-		"         gid: [optional, only for `type=file`]",
+		"         gid: [optional, only for types writing files]",
 		"              Group ID of file. Default is current user's Gid.",
-		"         uid: [optional, only for `type=file`]",
+		"         uid: [optional, only for types writing files]",
 		"              User ID of file. Default is current user's Uid.",
+		"     cleanup: [optional, only for types writing files]",
+		"              Remove file when the command exits. Implies -supervise.",
+		"              If the file existed before, it will be restored instead.",
+		"followsymlink: [optional, only for `type=file`, `type=bundle` and `type=template`]",
+		"              Write into the target of symlink at `to`. By default, writing to symlink is refused.",
+		"      decode: [optional, only for `type=env`, `type=file` and `type=dir`]",
+		"              Decode values before exporting. `base64`, `base64url`, `hex` or `gzip+base64`.",
+		"         key: [optional, only for `type=env`, `type=file` and `type=dir`]",
+		"              Export only a field of JSON value, like `.password`, `.tls.cert`, `.hosts[0]` or `.[\"key.with.dot\"]`.",
+		"              Objects and arrays are exported as JSON. Missing field is an error.",
+		" keyoptional: [optional, only with `key`]",
+		"              Export empty value instead of failing when the field selected by `key` is missing.",
+		"        list: [optional, only for `type=env`, `type=file` and `type=dir`]",
+		"              Export items of StringList parameters.",
+		"              If `list=index`, each item is exported as NAME_0, NAME_1, ... and the number of items as NAME_COUNT. Only for `type=env`.",
+		"              If `list=join`, items are joined by `listsep`.",
+		"     listsep: [optional, only with `list=join`]",
+		"              Separator of joined items. Escape sequences like `\\n`, `\\t` and `\\x2c` (comma) are allowed. Default is a space.",
+		"       mkdir: [optional, only for `type=file`, `type=bundle` and `type=template`]",
+		"              Create missing parent directories of `to`.",
+		"     dirmode: [optional, only for types writing files]",
+		"              Mode of created directories. Default is 0755.",
+		"      diruid: [optional, only for types writing files]",
+		"              User ID of created directories. Default is current user's Uid.",
+		"      dirgid: [optional, only for types writing files]",
+		"              Group ID of created directories. Default is current user's Gid.",
+		"    required: [optional]",
+		"              Fail if no parameter is found at `path`. By default, missing parameters are skipped.",
 	}, "\n"))
 	fs.Var(&flags.EnvFlags, "env", "Alias of `rule` flag with `type=env`.")
 	fs.Var(&flags.FileFlags, "file", "Alias of `rule` flag with `type=file`.")
@@ -108,7 +271,7 @@ func parseFlags(args []string, flagEnvPrefix string) (*Flags, []string, error) {
 		multiple := false
 
 		switch f.Name {
-		case "rule", "env", "file":
+		case "rule", "env", "file", "keep-env":
 			multiple = true
 		}
 
@@ -128,14 +291,46 @@ func parseFlags(args []string, flagEnvPrefix string) (*Flags, []string, error) {
 	return flags, fs.Args(), nil
 }
 
+// Subcommands of ssmwrap. Without subcommand, ssmwrap runs the command.
+const (
+	// subcommandExport only exports parameters without running command.
+	subcommandExport = "export"
+
+	// subcommandShellEnv prints statements to set environment variables for shell.
+	// It is not named `env` not to collide with env(1) which is commonly run by ssmwrap.
+	subcommandShellEnv = "shell-env"
+)
+
+// splitSubcommand returns the subcommand and the rest of args.
+// Subcommand must be the first argument, before any flags.
+// If args contain `--`, the first argument is not a subcommand but a part of the command to run,
+// so that commands named as same as subcommands can be run like `ssmwrap -- export`.
+func splitSubcommand(args []string) (string, []string) {
+	if len(args) == 0 || lo.Contains(args, "--") {
+		return "", args
+	}
+
+	switch args[0] {
+	case subcommandExport, subcommandShellEnv:
+		return args[0], args[1:]
+	default:
+		return "", args
+	}
+}
+
 // Run runs ssmwrap as a CLI, returns exit code.
+// If the first argument is `export`, ssmwrap only exports parameters without running command.
+// If the first argument is `shell-env`, ssmwrap prints statements to set environment variables for shell.
+// See splitSubcommand for details.
 func Run(version string, flagEnvPrefix string) ExitStatus {
 	ssmwrap.InitLogger()
 
-	flags, restArgs, err := parseFlags(os.Args[1:], flagEnvPrefix)
+	subcommand, args := splitSubcommand(os.Args[1:])
+
+	flags, restArgs, err := parseFlags(args, flagEnvPrefix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s", err)
-		return ExitStatusError
+		return ExitStatusInvalidArguments
 	}
 
 	if flags.VersionFlag {
@@ -147,9 +342,13 @@ func Run(version string, flagEnvPrefix string) ExitStatus {
 	if (0 < len(command)) && (command[0] == "--") {
 		command = command[1:]
 	}
-	if len(command) == 0 {
+	if subcommand != "" && 0 < len(command) {
+		fmt.Fprintf(os.Stderr, "command is not allowed in %s mode\n", subcommand)
+		return ExitStatusInvalidArguments
+	}
+	if subcommand == "" && len(command) == 0 {
 		fmt.Fprintln(os.Stderr, "command required in arguments")
-		return ExitStatusError
+		return ExitStatusInvalidArguments
 	}
 
 	rules := []app.Rule{}
@@ -158,7 +357,7 @@ func Run(version string, flagEnvPrefix string) ExitStatus {
 	rules = append(rules, flags.FileFlags.Rules...)
 	if len(rules) == 0 {
 		fmt.Fprintf(os.Stderr, "At least one rule required\n")
-		return ExitStatusError
+		return ExitStatusInvalidArguments
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT)
@@ -168,16 +367,133 @@ func Run(version string, flagEnvPrefix string) ExitStatus {
 	if flags.Retries != 0 {
 		sw.Retries = flags.Retries
 	}
+	sw.Timeout = flags.Timeout
+	sw.RequestTimeout = flags.RequestTimeout
+	sw.Supervise = flags.Supervise
+	sw.RefreshInterval = flags.RefreshInterval
+	sw.CleanupFiles = flags.CleanupFiles
+	sw.CleanEnv = flags.CleanEnv
+	sw.KeepEnv = flags.KeepEnv.Patterns
+	sw.ConfigEnvPrefix = flagEnvPrefix
+
+	if flags.StopSignal != "" {
+		sig, err := app.ParseSignal(flags.StopSignal)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -stop-signal: %s\n", err)
+			return ExitStatusInvalidArguments
+		}
+
+		sw.StopSignal = sig
+	}
+	sw.StopTimeout = flags.StopTimeout
+
+	if flags.Restart != "" {
+		policy, err := app.ParseRestartPolicy(flags.Restart)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -restart: %s\n", err)
+			return ExitStatusInvalidArguments
+		}
+
+		sw.Restart = policy
+	}
+	if flags.MaxRestarts != 0 {
+		sw.MaxRestarts = flags.MaxRestarts
+	}
+	if flags.RestartBackoff != 0 {
+		sw.RestartBackoff = flags.RestartBackoff
+	}
+
+	if flags.ReloadSignal != "" {
+		if flags.RefreshInterval == 0 {
+			fmt.Fprintln(os.Stderr, "-reload-signal requires -refresh-interval")
+			return ExitStatusInvalidArguments
+		}
+
+		sig, err := app.ParseSignal(flags.ReloadSignal)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -reload-signal: %s\n", err)
+			return ExitStatusInvalidArguments
+		}
+
+		sw.ReloadSignal = sig
+	}
+
+	switch subcommand {
+	case subcommandExport:
+		return export(ctx, sw, rules)
+	case subcommandShellEnv:
+		shell := app.ShellBash
+		if flags.Shell != "" {
+			shell, err = app.ParseShell(flags.Shell)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid -shell: %s\n", err)
+				return ExitStatusInvalidArguments
+			}
+		}
+
+		return shellEnv(ctx, sw, rules, shell)
+	}
 
 	if err := sw.Run(ctx, rules, command); err != nil {
-		if errors.Is(err, context.Canceled) {
-			fmt.Fprintf(os.Stderr, "Interrupted\n")
-		} else {
-			fmt.Fprintf(os.Stderr, "Error occurred: %s\n", err)
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) {
+			printError(err)
 		}
 
-		return ExitStatusError
+		return exitStatusOf(err)
+	}
+
+	return ExitStatusOK
+}
+
+// export exports parameters without running command, and prints paths of written files.
+func export(ctx context.Context, sw *app.SSMWrap, rules []app.Rule) ExitStatus {
+	if lo.SomeBy(rules, func(r app.Rule) bool {
+		return r.DestinationRule.Type == app.DestinationTypeEnv
+	}) {
+		slog.Warn("rules for `type=env` have no effect in export mode")
+	}
+
+	exported, err := sw.Export(ctx, rules)
+	if err != nil {
+		printError(err)
+		return exitStatusOf(err)
 	}
 
+	fmt.Printf("%d files exported\n", len(exported.Files))
+	for _, path := range exported.Files {
+		fmt.Printf("  %s\n", path)
+	}
+
+	return ExitStatusOK
+}
+
+// shellEnv exports parameters by rules for `type=env`, and prints statements to set them in shell.
+func shellEnv(ctx context.Context, sw *app.SSMWrap, rules []app.Rule, shell app.Shell) ExitStatus {
+	envRules, otherRules := lo.FilterReject(rules, func(r app.Rule, _ int) bool {
+		return r.DestinationRule.Type == app.DestinationTypeEnv
+	})
+	if 0 < len(otherRules) {
+		slog.Warn("only rules for `type=env` have effect in shell-env mode")
+	}
+	if len(envRules) == 0 {
+		fmt.Fprintln(os.Stderr, "At least one rule for `type=env` required in shell-env mode")
+		return ExitStatusInvalidArguments
+	}
+
+	exported, err := sw.Export(ctx, envRules)
+	if err != nil {
+		printError(err)
+		return exitStatusOf(err)
+	}
+
+	script, err := shell.Script(exported.Env)
+	if err != nil {
+		printError(err)
+		return exitStatusOf(err)
+	}
+
+	fmt.Print(script)
+
 	return ExitStatusOK
 }
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
+	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/handlename/ssmwrap/v2/internal/app"
@@ -79,6 +83,7 @@ func TestParseFlag(t *testing.T) {
 			expected: &Flags{
 				VersionFlag: false,
 				Retries:     3,
+				StopTimeout: 10 * time.Second,
 				RuleFlags: cli.RuleFlags{
 					Rules: []app.Rule{
 						envRules[0],
@@ -116,6 +121,7 @@ func TestParseFlag(t *testing.T) {
 			expected: &Flags{
 				VersionFlag: false,
 				Retries:     0,
+				StopTimeout: 10 * time.Second,
 				RuleFlags: cli.RuleFlags{
 					Rules: []app.Rule{
 						envRules[0],
@@ -163,6 +169,7 @@ func TestParseFlag(t *testing.T) {
 			expected: &Flags{
 				VersionFlag: false,
 				Retries:     3,
+				StopTimeout: 10 * time.Second,
 				RuleFlags: cli.RuleFlags{
 					Rules: []app.Rule{
 						envRules[0],
@@ -187,6 +194,16 @@ func TestParseFlag(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "valid: stop-timeout 0",
+			flags: []string{"-stop-timeout", "0", "-rule", envRules[0].String()},
+			expected: &Flags{
+				StopTimeout: 0,
+				RuleFlags: cli.RuleFlags{
+					Rules: []app.Rule{envRules[0]},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -212,3 +229,152 @@ func TestParseFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitSubcommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		wantSubcommand string
+		wantArgs       []string
+	}{
+		{
+			name:           "export",
+			args:           []string{"export", "-file", "path=/foo,to=/tmp/foo"},
+			wantSubcommand: "export",
+			wantArgs:       []string{"-file", "path=/foo,to=/tmp/foo"},
+		},
+		{
+			name:           "export after flags is a command",
+			args:           []string{"-env", "path=/foo", "export"},
+			wantSubcommand: "",
+			wantArgs:       []string{"-env", "path=/foo", "export"},
+		},
+		{
+			name:           "export with separator is a command",
+			args:           []string{"export", "--", "app"},
+			wantSubcommand: "",
+			wantArgs:       []string{"export", "--", "app"},
+		},
+		{
+			name:           "shell-env",
+			args:           []string{"shell-env", "-shell", "fish"},
+			wantSubcommand: "shell-env",
+			wantArgs:       []string{"-shell", "fish"},
+		},
+		{
+			name:           "env is a command",
+			args:           []string{"env"},
+			wantSubcommand: "",
+			wantArgs:       []string{"env"},
+		},
+		{
+			name:           "no subcommand",
+			args:           []string{"-env", "path=/foo", "--", "app"},
+			wantSubcommand: "",
+			wantArgs:       []string{"-env", "path=/foo", "--", "app"},
+		},
+		{
+			name:           "no args",
+			args:           []string{},
+			wantSubcommand: "",
+			wantArgs:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subcommand, args := splitSubcommand(tt.args)
+
+			if subcommand != tt.wantSubcommand {
+				t.Errorf("unexpected subcommand: %q", subcommand)
+			}
+
+			if diff := cmp.Diff(tt.wantArgs, args); diff != "" {
+				t.Errorf("args have diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnvCommandIsExecuted(t *testing.T) {
+	for _, args := range [][]string{
+		{"--", "env"},
+		{"-env", "path=/foo", "--", "env"},
+	} {
+		subcommand, args := splitSubcommand(args)
+		if subcommand != "" {
+			t.Fatalf("unexpected subcommand: %s", subcommand)
+		}
+
+		_, command, err := parseFlags(args, "SSMWRAP_TEST_")
+		if err != nil {
+			t.Fatalf("failed to parse flags: %s", err)
+		}
+
+		if diff := cmp.Diff([]string{"env"}, command); diff != "" {
+			t.Errorf("command has diff:\n%s", diff)
+		}
+	}
+}
+
+func TestExitStatusOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ExitStatus
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: ExitStatusOK,
+		},
+		{
+			name: "unclassified",
+			err:  errors.New("unknown"),
+			want: ExitStatusError,
+		},
+		{
+			name: "aws config",
+			err:  fmt.Errorf("wrapped: %w", &app.AWSConfigError{Err: errors.New("no credentials")}),
+			want: ExitStatusAWSConfigError,
+		},
+		{
+			name: "fetch",
+			err:  &app.FetchError{Err: errors.New("throttled")},
+			want: ExitStatusFetchError,
+		},
+		{
+			name: "parameter not found",
+			err:  fmt.Errorf("wrapped: %w", &app.ParameterNotFoundError{Path: "/foo"}),
+			want: ExitStatusParameterNotFound,
+		},
+		{
+			name: "destination",
+			err:  &app.DestinationError{Address: "/path/to/file", Err: errors.New("permission denied")},
+			want: ExitStatusDestinationError,
+		},
+		{
+			name: "command not found",
+			err:  &app.CommandError{Command: "foo", Err: exec.ErrNotFound},
+			want: ExitStatusCommandNotFound,
+		},
+		{
+			name: "command not executable",
+			err:  &app.CommandError{Command: "foo", Err: fs.ErrPermission},
+			want: ExitStatusCommandNotExecutable,
+		},
+		{
+			name: "exit status of command",
+			err:  &app.ExitError{Code: 42},
+			want: ExitStatus(42),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitStatusOf(tt.err); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,18 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/samber/lo"
@@ -17,13 +23,82 @@ type SSMWrap struct {
 	// Retry limit to request to SSM.
 	Retries int
 
+	// Timeout is timeout for whole of fetching parameters.
+	// If Timeout is 0, fetching never times out by itself.
+	Timeout time.Duration
+
+	// RequestTimeout is timeout for each request to SSM.
+	// If RequestTimeout is 0, requests never time out by itself.
+	RequestTimeout time.Duration
+
 	// Command and arguments to run.
 	Command []string
+
+	// Supervise runs the command as a child process instead of replacing ssmwrap process.
+	// Signals sent to ssmwrap are forwarded to the command.
+	Supervise bool
+
+	// RefreshInterval is interval to refresh parameters in supervisor mode.
+	// If any parameter is changed, the command will be restarted.
+	// If RefreshInterval is 0, parameters will not be refreshed.
+	RefreshInterval time.Duration
+
+	// StopSignal is sent to the command when ssmwrap receives SIGTERM or restarts the command.
+	StopSignal syscall.Signal
+
+	// StopTimeout is time to wait for the command to exit after StopSignal is sent.
+	// After the timeout, the command will be killed by SIGKILL.
+	// If StopTimeout is 0, ssmwrap waits for the command forever.
+	StopTimeout time.Duration
+
+	// Restart is a policy to restart the command when it exits in supervisor mode.
+	// Parameters are fetched again before restarting.
+	Restart RestartPolicy
+
+	// MaxRestarts is upper limit of the number of restarts by Restart policy.
+	// If MaxRestarts is 0, the command is restarted unlimitedly.
+	MaxRestarts int
+
+	// RestartBackoff is delay before the first restart by Restart policy.
+	// The delay is doubled on each restart.
+	RestartBackoff time.Duration
+
+	// ReloadSignal is sent to the command instead of restarting it
+	// when only parameters for `type=file` or `type=bundle` rules are changed on refresh.
+	// If ReloadSignal is 0, the command will be restarted.
+	ReloadSignal syscall.Signal
+
+	// CleanupFiles removes all exported files when the command exits in supervisor mode.
+	// Otherwise, only files for rules with `cleanup=true` are removed.
+	CleanupFiles bool
+
+	// CleanEnv passes only exported parameters and environment variables matched with KeepEnv to the command.
+	CleanEnv bool
+
+	// KeepEnv is a list of glob patterns of environment variable names passed to the command.
+	// Matched variables are passed even if CleanEnv is true or they start with ConfigEnvPrefix.
+	KeepEnv []string
+
+	// ConfigEnvPrefix is a prefix of environment variables to configure ssmwrap.
+	// Variables start with it are not passed to the command.
+	ConfigEnvPrefix string
+
+	cleaner *FileCleaner
+
+	// connector fetches parameters from SSM. If connector is nil, DefaultSSMConnector is used.
+	connector SSMConnector
 }
 
+// DefaultStopTimeout is default of SSMWrap.StopTimeout.
+const DefaultStopTimeout = 10 * time.Second
+
 func NewSSMWrap() *SSMWrap {
 	return &SSMWrap{
-		Retries: 3,
+		Retries:        3,
+		StopSignal:     syscall.SIGTERM,
+		StopTimeout:    DefaultStopTimeout,
+		Restart:        RestartPolicyNever,
+		RestartBackoff: time.Second,
 	}
 }
 
@@ -32,50 +107,464 @@ func (s *SSMWrap) Run(ctx context.Context, rules []Rule, command []string) error
 		return fmt.Errorf("command required")
 	}
 
-	if err := s.Export(ctx, rules); err != nil {
+	supervise := s.Supervise ||
+		0 < s.RefreshInterval ||
+		(s.Restart != "" && s.Restart != RestartPolicyNever) ||
+		lo.SomeBy(rules, s.needsCleanup)
+
+	if supervise {
+		s.cleaner = NewFileCleaner()
+		defer func() {
+			if err := s.cleaner.Clean(); err != nil {
+				slog.Warn("failed to clean up files", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	store, err := s.fetch(ctx, rules)
+	if err != nil {
+		return fmt.Errorf("failed to export parameters: %w", err)
+	}
+
+	exported, err := s.execute(rules, *store)
+	if err != nil {
 		return fmt.Errorf("failed to export parameters: %w", err)
 	}
 
+	if supervise {
+		return s.supervise(ctx, rules, *store, exported, command)
+	}
+
 	bin, err := exec.LookPath(command[0])
 	if err != nil {
-		return fmt.Errorf("command is not executable %s: %w", command[0], err)
+		return &CommandError{Command: command[0], Err: err}
+	}
+
+	if err := syscall.Exec(bin, command, s.environ(exported)); err != nil {
+		return &CommandError{Command: command[0], Err: err}
+	}
+
+	return nil
+}
+
+// supervise runs the command as a child process and waits for it to exit,
+// forwarding signals received by ssmwrap to the command.
+// SIGTERM is not forwarded as is, but the command is stopped by StopSignal and StopTimeout.
+// If RefreshInterval is set, parameters are refreshed periodically
+// and the command is restarted when any of them is changed.
+// When the command exits, it is restarted according to Restart policy.
+func (s *SSMWrap) supervise(ctx context.Context, rules []Rule, store ParameterStore, exported *Exported, command []string) error {
+	// From here, signals like SIGINT are handled by the loop below and forwarded to the command.
+	// Parameters must be fetched on refresh and restart even if ctx is canceled by such a signal.
+	ctx = context.WithoutCancel(ctx)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, forwardedSignals...)
+	defer signal.Stop(sigCh)
+
+	// Reap orphaned processes as init process, e.g. ENTRYPOINT of a container.
+	var reaper *Reaper
+	if os.Getpid() == 1 {
+		slog.Debug("running as PID 1, reaping orphaned processes")
+
+		reaperCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		reaper = NewReaper()
+		go reaper.Run(reaperCtx)
+	}
+
+	sv, err := s.startCommand(command, exported, reaper)
+	if err != nil {
+		return err
+	}
+
+	var refreshCh <-chan time.Time
+	if 0 < s.RefreshInterval {
+		ticker := time.NewTicker(s.RefreshInterval)
+		defer ticker.Stop()
+
+		refreshCh = ticker.C
+	}
+
+	var (
+		stopping bool
+		killCh   <-chan time.Time
+
+		// restarting is true while waiting for the command to stop to restart it with refreshed parameters.
+		restarting bool
+
+		// interrupted is true if SIGINT or SIGQUIT is forwarded to the command.
+		// The command is not restarted by Restart policy when it exits,
+		// but parameters are still refreshed while it survives.
+		interrupted bool
+
+		// doneCh is nil while waiting for restart.
+		doneCh    = sv.Done()
+		restartCh <-chan time.Time
+		restarts  int
+	)
+
+	for {
+		select {
+		case sig := <-sigCh:
+			if doneCh == nil {
+				switch sig {
+				case syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP:
+					slog.Info("stopped while waiting for restart", slog.String("signal", sig.String()))
+					return &ExitError{Code: 128 + int(sig.(syscall.Signal))}
+				}
+
+				continue
+			}
+
+			if sig == syscall.SIGTERM {
+				if stopping {
+					continue
+				}
+
+				if restarting {
+					// StopSignal is already sent, and the command is not started again.
+					slog.Info("stopping command instead of restarting")
+
+					stopping = true
+					restarting = false
+
+					continue
+				}
+
+				slog.Info("stopping command", slog.String("signal", s.StopSignal.String()))
+
+				stopping = true
+				if 0 < s.StopTimeout {
+					killCh = time.After(s.StopTimeout)
+				}
+
+				sig = s.StopSignal
+			}
+
+			if sig == syscall.SIGINT || sig == syscall.SIGQUIT {
+				interrupted = true
+
+				if restarting {
+					slog.Info("stopping command instead of restarting", slog.String("signal", sig.String()))
+
+					stopping = true
+					restarting = false
+				}
+			}
+
+			slog.Debug("forwarding signal", slog.String("signal", sig.String()))
+
+			if err := sv.Signal(sig); err != nil {
+				slog.Warn("failed to forward signal", slog.String("signal", sig.String()), slog.String("error", err.Error()))
+			}
+		case <-killCh:
+			slog.Warn("command did not stop in time, killing it", slog.Duration("timeout", s.StopTimeout))
+
+			if err := sv.Signal(syscall.SIGKILL); err != nil {
+				slog.Warn("failed to kill command", slog.String("error", err.Error()))
+			}
+		case <-refreshCh:
+			if stopping || restarting || doneCh == nil {
+				continue
+			}
+
+			// The AWS SDK may run credential_process, which must not be reaped by reaper.
+			release := reaper.Hold()
+			next, changed, err := s.refresh(ctx, rules, store)
+			release()
+
+			if err != nil {
+				slog.Warn("failed to refresh parameters", slog.String("error", err.Error()))
+				continue
+			}
+
+			if len(changed) == 0 {
+				slog.Debug("no parameters changed")
+				continue
+			}
+
+			targets := changedRules(rules, store, *next)
+
+			if s.ReloadSignal != 0 && lo.EveryBy(targets, func(r Rule) bool {
+				return r.DestinationRule.WritesFile()
+			}) {
+				slog.Info("parameters changed, reloading command", slog.String("parameters", strings.Join(changed, ",")))
+
+				if _, err := s.execute(targets, *next); err != nil {
+					slog.Warn("failed to export refreshed parameters", slog.String("error", err.Error()))
+					continue
+				}
+
+				store = *next
+
+				if err := sv.Signal(s.ReloadSignal); err != nil {
+					slog.Warn("failed to send reload signal", slog.String("error", err.Error()))
+				}
+
+				continue
+			}
+
+			slog.Info("parameters changed, restarting command", slog.String("parameters", strings.Join(changed, ",")))
+
+			e, err := s.execute(rules, *next)
+			if err != nil {
+				slog.Warn("failed to export refreshed parameters", slog.String("error", err.Error()))
+				continue
+			}
+
+			store = *next
+			exported = e
+
+			// The command is started again when it exits. See the case of doneCh.
+			restarting = true
+			if 0 < s.StopTimeout {
+				killCh = time.After(s.StopTimeout)
+			}
+
+			if err := sv.Signal(s.StopSignal); err != nil {
+				slog.Warn("failed to send stop signal", slog.String("error", err.Error()))
+			}
+		case <-doneCh:
+			err := sv.Wait()
+
+			if restarting {
+				restarting = false
+				interrupted = false
+				killCh = nil
+
+				slog.Debug("command stopped, starting again", slog.Any("exit", err))
+
+				sv, err = s.startCommand(command, exported, reaper)
+				if err != nil {
+					return err
+				}
+				doneCh = sv.Done()
+
+				continue
+			}
+
+			if stopping || !s.Restart.ShouldRestart(err) {
+				return err
+			}
+
+			if interrupted {
+				slog.Info("command exited after interrupted, not restarting", slog.Any("exit", err))
+				return err
+			}
+
+			if 0 < s.MaxRestarts && s.MaxRestarts <= restarts {
+				slog.Warn("command exited, but reached max restarts", slog.Int("restarts", restarts))
+				return err
+			}
+
+			delay := restartBackoff(s.RestartBackoff, restarts)
+			restarts++
+
+			slog.Warn(
+				"command exited, restarting",
+				slog.Any("exit", err),
+				slog.Int("restarts", restarts),
+				slog.Duration("delay", delay),
+			)
+
+			doneCh = nil
+			restartCh = time.After(delay)
+		case <-restartCh:
+			restartCh = nil
+
+			// Use fresh parameters in case of the exit was caused by stale ones, e.g. rotated credentials.
+			release := reaper.Hold()
+			next, err := s.fetch(ctx, rules)
+			release()
+
+			if err != nil {
+				slog.Warn("failed to fetch parameters, restarting with current ones", slog.String("error", err.Error()))
+			} else if e, err := s.execute(rules, *next); err != nil {
+				slog.Warn("failed to export parameters, restarting with current ones", slog.String("error", err.Error()))
+			} else {
+				store = *next
+				exported = e
+			}
+
+			sv, err = s.startCommand(command, exported, reaper)
+			if err != nil {
+				return err
+			}
+			doneCh = sv.Done()
+		}
+	}
+}
+
+// startCommand starts the command with exported parameters.
+func (s SSMWrap) startCommand(command []string, exported *Exported, reaper *Reaper) (*Supervisor, error) {
+	sv := NewSupervisor(command, s.environ(exported))
+	sv.Reaper = reaper
+
+	if err := sv.Start(); err != nil {
+		return nil, err
+	}
+
+	return sv, nil
+}
+
+// refresh fetches parameters again.
+// It returns new store and paths of parameters changed from current.
+func (s SSMWrap) refresh(ctx context.Context, rules []Rule, current ParameterStore) (*ParameterStore, []string, error) {
+	next, err := s.fetch(ctx, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return next, current.Diff(*next), nil
+}
+
+// changedRules returns rules whose parameters are different between current and next.
+func changedRules(rules []Rule, current ParameterStore, next ParameterStore) []Rule {
+	return lo.Filter(rules, func(r Rule, _ int) bool {
+		before, _ := current.Retrieve(r.ParameterRule.Path, r.ParameterRule.Level)
+		after, _ := next.Retrieve(r.ParameterRule.Path, r.ParameterRule.Level)
+
+		return 0 < len(ParameterStore{Parameters: before}.Diff(ParameterStore{Parameters: after}))
+	})
+}
+
+// Export fetches parameters and exports them according to rules.
+// Exported environment variables are returned instead of setting them to the process.
+func (s SSMWrap) Export(ctx context.Context, rules []Rule) (*Exported, error) {
+	store, err := s.fetch(ctx, rules)
+	if err != nil {
+		return nil, err
 	}
 
-	return syscall.Exec(bin, command, os.Environ())
+	return s.execute(rules, *store)
 }
 
-func (s SSMWrap) Export(ctx context.Context, rules []Rule) error {
+// fetch fetches parameters related to rules from SSM.
+func (s SSMWrap) fetch(ctx context.Context, rules []Rule) (*ParameterStore, error) {
 	slog.DebugContext(ctx, fmt.Sprintf("start to process %d rules", len(rules)))
 
+	if 0 < s.Timeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
 	ssmClient, err := s.ssmClient(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// store related ssm params
 
 	slog.DebugContext(ctx, "start to store parameters")
 
-	store := NewParameterStore(ssmClient, DefaultSSMConnector{})
+	var conn SSMConnector = DefaultSSMConnector{
+		RequestTimeout: s.RequestTimeout,
+	}
+	if s.connector != nil {
+		conn = s.connector
+	}
+
+	store := NewParameterStore(ssmClient, conn)
 	if err := store.Store(ctx, lo.Map(rules, func(r Rule, _ int) ParameterRule {
 		return r.ParameterRule
 	})); err != nil {
-		return fmt.Errorf("failed to refresh parameters: %w", err)
+		var configErr *AWSConfigError
+		if errors.As(err, &configErr) {
+			return nil, configErr
+		}
+
+		return nil, &FetchError{Err: err}
 	}
 
 	slog.DebugContext(ctx, fmt.Sprintf("%d parameters stored successfully", len(store.Parameters)))
 
-	// execute rules
+	return store, nil
+}
+
+// execute exports parameters in store according to rules.
+func (s SSMWrap) execute(rules []Rule, store ParameterStore) (*Exported, error) {
+	exported := NewExported()
 
 	for _, r := range rules {
-		slog.DebugContext(ctx, "executing rule", slog.String("rule", r.String()))
+		slog.Debug("executing rule", slog.String("rule", r.String()))
 
-		if err := r.Execute(*store); err != nil {
-			return fmt.Errorf("failed to execute rule %s: %w", r, err)
+		cleanup := s.cleaner != nil && s.needsCleanup(r)
+
+		// `type=dir` rules report files they created instead, because they never overwrite files.
+		if cleanup && r.DestinationRule.Type != DestinationTypeDir {
+			if err := s.cleaner.Track(r.DestinationRule.To); err != nil {
+				return nil, fmt.Errorf("failed to prepare cleanup for rule %s: %w", r, err)
+			}
+		}
+
+		n := len(exported.Created)
+		err := r.Execute(store, exported)
+
+		if cleanup {
+			for _, path := range exported.Created[n:] {
+				s.cleaner.TrackCreated(path)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute rule %s: %w", r, err)
 		}
 	}
 
-	return nil
+	return exported, nil
+}
+
+// environ returns environment variables passed to the command.
+// It consists of filtered environment variables of ssmwrap and exported ones.
+func (s SSMWrap) environ(exported *Exported) []string {
+	env := NewEnv(s.filterEnv(os.Environ()))
+	for name, value := range exported.Env {
+		env[name] = value
+	}
+
+	return env.Environ()
+}
+
+// filterEnv filters environ in form of `KEY=VALUE` by CleanEnv, KeepEnv and ConfigEnvPrefix.
+func (s SSMWrap) filterEnv(environ []string) []string {
+	filtered := make([]string, 0, len(environ))
+
+	for _, env := range environ {
+		name := strings.SplitN(env, "=", 2)[0]
+
+		keep := lo.SomeBy(s.KeepEnv, func(pattern string) bool {
+			matched, _ := path.Match(pattern, name)
+			return matched
+		})
+
+		if !keep {
+			if s.CleanEnv {
+				continue
+			}
+
+			if s.ConfigEnvPrefix != "" && strings.HasPrefix(name, s.ConfigEnvPrefix) {
+				continue
+			}
+		}
+
+		filtered = append(filtered, env)
+	}
+
+	return filtered
+}
+
+// needsCleanup reports whether files exported by the rule should be cleaned up.
+func (s SSMWrap) needsCleanup(r Rule) bool {
+	if !r.DestinationRule.WritesFile() {
+		return false
+	}
+
+	opts := r.DestinationRule.FileOptions()
+
+	return s.CleanupFiles || (opts != nil && opts.Cleanup)
 }
 
 func (s SSMWrap) ssmClient(ctx context.Context) (*ssm.Client, error) {
@@ -87,8 +576,39 @@ func (s SSMWrap) ssmClient(ctx context.Context) (*ssm.Client, error) {
 
 	conf, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load default aws config: %w", err)
+		return nil, &AWSConfigError{Err: err}
+	}
+
+	if conf.Region == "" {
+		return nil, &AWSConfigError{Err: fmt.Errorf("no region is configured")}
+	}
+
+	if conf.Credentials == nil {
+		return nil, &AWSConfigError{Err: fmt.Errorf("no credentials provider")}
 	}
 
+	conf.Credentials = credentialsProvider{conf.Credentials}
+
 	return ssm.NewFromConfig(conf), nil
 }
+
+// credentialsProvider wraps errors of retrieving credentials with AWSConfigError,
+// to distinguish credential problems from other failures of SSM calls.
+// Credentials are retrieved lazily by the SSM client as usual.
+type credentialsProvider struct {
+	aws.CredentialsProvider
+}
+
+func (p credentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.CredentialsProvider.Retrieve(ctx)
+	if err != nil {
+		return creds, &AWSConfigError{Err: fmt.Errorf("failed to retrieve credentials: %w", err)}
+	}
+
+	return creds, nil
+}
+
+// IsCredentialsProvider lets the SDK see through the wrapper. See aws.IsCredentialsProvider.
+func (p credentialsProvider) IsCredentialsProvider(target aws.CredentialsProvider) bool {
+	return aws.IsCredentialsProvider(p.CredentialsProvider, target)
+}
@@ -1,24 +1,409 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/google/go-cmp/cmp"
 )
 
-type EnvCleaner struct {
-	orig []string
+func TestChangedRules(t *testing.T) {
+	envRule := Rule{
+		ParameterRule:   ParameterRule{Path: "/env/", Level: ParameterLevelUnder},
+		DestinationRule: DestinationRule{Type: DestinationTypeEnv, TypeEnvOptions: &DestinationTypeEnvOptions{}},
+	}
+	fileRule := Rule{
+		ParameterRule:   ParameterRule{Path: "/file/cert", Level: ParameterLevelStrict},
+		DestinationRule: DestinationRule{Type: DestinationTypeFile, To: "/path/to/cert", TypeFileOptions: &DestinationTypeFileOptions{}},
+	}
+	rules := []Rule{envRule, fileRule}
+
+	current := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/env/v1", Value: "v1"},
+			{Path: "/file/cert", Value: "cert"},
+		},
+	}
+
+	tests := []struct {
+		title string
+		next  []Parameter
+		want  []Rule
+	}{
+		{
+			title: "no changes",
+			next:  current.Parameters,
+			want:  []Rule{},
+		},
+		{
+			title: "file changed",
+			next: []Parameter{
+				{Path: "/env/v1", Value: "v1"},
+				{Path: "/file/cert", Value: "new cert"},
+			},
+			want: []Rule{fileRule},
+		},
+		{
+			title: "env added",
+			next: []Parameter{
+				{Path: "/env/v1", Value: "v1"},
+				{Path: "/env/v2", Value: "v2"},
+				{Path: "/file/cert", Value: "cert"},
+			},
+			want: []Rule{envRule},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := changedRules(rules, current, ParameterStore{Parameters: tt.next})
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("changedRules() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSSMWrapFilterEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/root",
+		"AWS_REGION=ap-northeast-1",
+		"SSMWRAP_ENV=path=/foo/*",
+	}
+
+	tests := []struct {
+		title string
+		sw    SSMWrap
+		want  []string
+	}{
+		{
+			title: "default",
+			sw:    SSMWrap{},
+			want:  environ,
+		},
+		{
+			title: "without config variables",
+			sw: SSMWrap{
+				ConfigEnvPrefix: "SSMWRAP_",
+			},
+			want: []string{
+				"PATH=/usr/bin",
+				"HOME=/root",
+				"AWS_REGION=ap-northeast-1",
+			},
+		},
+		{
+			title: "clean env",
+			sw: SSMWrap{
+				CleanEnv:        true,
+				ConfigEnvPrefix: "SSMWRAP_",
+			},
+			want: []string{},
+		},
+		{
+			title: "clean env with keep patterns",
+			sw: SSMWrap{
+				CleanEnv:        true,
+				KeepEnv:         []string{"PATH", "AWS_*", "SSMWRAP_*"},
+				ConfigEnvPrefix: "SSMWRAP_",
+			},
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_REGION=ap-northeast-1",
+				"SSMWRAP_ENV=path=/foo/*",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := tt.sw.filterEnv(environ)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("filterEnv() has diff:\n%s", diff)
+			}
+		})
+	}
 }
 
-func (e *EnvCleaner) Clean() {
-	e.orig = os.Environ()
-	os.Clearenv()
+func TestCredentialsProviderReturnsAWSConfigError(t *testing.T) {
+	p := credentialsProvider{aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{}, fmt.Errorf("no EC2 IMDS role found")
+	})}
+
+	_, err := p.Retrieve(context.Background())
+
+	var configErr *AWSConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCredentialsProviderIsCredentialsProvider(t *testing.T) {
+	p := credentialsProvider{aws.AnonymousCredentials{}}
+
+	if !aws.IsCredentialsProvider(p, aws.AnonymousCredentials{}) {
+		t.Errorf("wrapped provider is not recognized as AnonymousCredentials")
+	}
 }
 
-func (e EnvCleaner) Restore() {
-	os.Clearenv()
+// valueSSMConnector returns value for any name. The value can be changed while running.
+type valueSSMConnector struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (c *valueSSMConnector) set(v string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.value = v
+}
+
+func (c *valueSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]Parameter, error) {
+	return map[string]Parameter{}, nil
+}
+
+func (c *valueSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]Parameter, error) {
+	// as same as requests to SSM
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := map[string]Parameter{}
+	for _, name := range names {
+		ret[name] = Parameter{Path: name, Value: c.value}
+	}
+
+	return ret, nil
+}
+
+// superviseTest runs SSMWrap in supervisor mode with a shell script as the command.
+// The script should record value of VALUE passed to each run into the file given as $1.
+type superviseTest struct {
+	t    *testing.T
+	conn *valueSSMConnector
+	out  string
+
+	// done is closed when SSMWrap returns err.
+	done chan struct{}
+	err  error
+}
+
+func startSuperviseTest(t *testing.T, ctx context.Context, sw *SSMWrap, script string) *superviseTest {
+	// credentials are not retrieved because the connector does not call SSM
+	t.Setenv("AWS_REGION", "ap-northeast-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "dummy")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "dummy")
+
+	st := &superviseTest{
+		t:    t,
+		conn: &valueSSMConnector{value: "v1"},
+		out:  filepath.Join(t.TempDir(), "out"),
+		done: make(chan struct{}),
+	}
+
+	sw.connector = st.conn
+
+	rules := []Rule{
+		{
+			ParameterRule: ParameterRule{Path: "/app/value", Level: ParameterLevelStrict},
+			DestinationRule: DestinationRule{
+				Type:           DestinationTypeEnv,
+				TypeEnvOptions: &DestinationTypeEnvOptions{},
+			},
+		},
+	}
+
+	command := []string{"sh", "-c", script, "sh", st.out}
+
+	go func() {
+		defer close(st.done)
+		st.err = sw.Run(ctx, rules, command)
+	}()
+
+	// stop the command left by failed test
+	t.Cleanup(func() {
+		select {
+		case <-st.done:
+		default:
+			syscall.Kill(os.Getpid(), syscall.SIGTERM)
+			<-st.done
+		}
+	})
+
+	return st
+}
+
+// waitFor waits until the command is run with value.
+func (st *superviseTest) waitFor(value string) {
+	st.t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		body, _ := os.ReadFile(st.out)
+		if strings.Contains(string(body), value+"\n") {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	st.t.Fatalf("command was not run with %s", value)
+}
+
+// wait waits for SSMWrap to return.
+func (st *superviseTest) wait() error {
+	st.t.Helper()
+
+	select {
+	case <-st.done:
+		return st.err
+	case <-time.After(5 * time.Second):
+		st.t.Fatalf("ssmwrap did not return")
+		return nil
+	}
+}
+
+func TestSSMWrapSuperviseRefreshesAfterSIGINT(t *testing.T) {
+	// same as cli
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT)
+	defer stop()
+
+	sw := NewSSMWrap()
+	sw.RefreshInterval = 50 * time.Millisecond
+
+	st := startSuperviseTest(t, ctx, sw, `trap "" INT; echo "$VALUE" >> "$1"; exec sleep 60`)
+	st.waitFor("v1")
+
+	// the command survives SIGINT forwarded by ssmwrap
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatalf("failed to send SIGINT: %s", err)
+	}
+
+	<-ctx.Done()
+
+	st.conn.set("v2")
+	st.waitFor("v2")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %s", err)
+	}
+
+	st.wait()
+}
+
+func TestSSMWrapSuperviseStopsWhileWaitingForRestart(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP} {
+		t.Run(sig.String(), func(t *testing.T) {
+			sw := NewSSMWrap()
+			sw.Restart = RestartPolicyOnFailure
+			sw.RestartBackoff = time.Minute
+
+			st := startSuperviseTest(t, context.Background(), sw, `echo "$VALUE" >> "$1"; exit 1`)
+			st.waitFor("v1")
+
+			// wait for the command to exit
+			time.Sleep(100 * time.Millisecond)
+
+			if err := syscall.Kill(os.Getpid(), sig); err != nil {
+				t.Fatalf("failed to send %s: %s", sig, err)
+			}
+
+			var exitErr *ExitError
+			if err := st.wait(); !errors.As(err, &exitErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if exitErr.Code != 128+int(sig) {
+				t.Errorf("unexpected exit code: %d", exitErr.Code)
+			}
+		})
+	}
+}
+
+func TestSSMWrapSuperviseDoesNotRestartInterruptedCommand(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGQUIT} {
+		t.Run(sig.String(), func(t *testing.T) {
+			sw := NewSSMWrap()
+			sw.Restart = RestartPolicyAlways
+			sw.RestartBackoff = 10 * time.Millisecond
+
+			st := startSuperviseTest(t, context.Background(), sw, `echo "$VALUE" >> "$1"; exec sleep 60`)
+			st.waitFor("v1")
+
+			if err := syscall.Kill(os.Getpid(), sig); err != nil {
+				t.Fatalf("failed to send %s: %s", sig, err)
+			}
+
+			var exitErr *ExitError
+			if err := st.wait(); !errors.As(err, &exitErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if exitErr.Code != 128+int(sig) {
+				t.Errorf("unexpected exit code: %d", exitErr.Code)
+			}
+
+			body, err := os.ReadFile(st.out)
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+
+			if string(body) != "v1\n" {
+				t.Errorf("command should not be restarted:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestSSMWrapSuperviseStopsWhileRestarting(t *testing.T) {
+	sw := NewSSMWrap()
+	sw.RefreshInterval = 50 * time.Millisecond
+	sw.StopTimeout = 500 * time.Millisecond
+
+	// the command does not stop by StopSignal, and is killed after StopTimeout
+	st := startSuperviseTest(t, context.Background(), sw, `trap 'echo stopping >> "$1"' TERM; echo "$VALUE" >> "$1"; while :; do sleep 0.05; done`)
+	st.waitFor("v1")
+
+	st.conn.set("v2")
+	st.waitFor("stopping")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %s", err)
+	}
+
+	var exitErr *ExitError
+	if err := st.wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exitErr.Code != 128+int(syscall.SIGKILL) {
+		t.Errorf("unexpected exit code: %d", exitErr.Code)
+	}
+
+	body, err := os.ReadFile(st.out)
+	if err != nil {
+		t.Fatalf("failed to read: %s", err)
+	}
 
-	for _, env := range e.orig {
-		p := strings.SplitN(env, "=", 2)
-		os.Setenv(p[0], p[1])
+	if strings.Contains(string(body), "v2") {
+		t.Errorf("command should not be restarted after SIGTERM:\n%s", body)
 	}
 }
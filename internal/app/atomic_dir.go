@@ -0,0 +1,262 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// atomicDirDataLink is name of symlink to the current snapshot directory.
+	atomicDirDataLink = "..data"
+
+	// atomicDirSnapshotLayout is time layout of prefix of snapshot directories.
+	atomicDirSnapshotLayout = "..2006_01_02_15_04_05."
+)
+
+// AtomicDirWriter writes a set of files into a directory atomically, as same as kubelet updates projected volumes.
+//
+// Files are written into a new timestamped snapshot directory, and then `..data` symlink is swapped to it by rename(2).
+// Each top-level entry in the directory is a symlink through `..data`, like `name -> ..data/name`.
+// So readers always see one consistent snapshot. Old snapshots are removed after swapping.
+type AtomicDirWriter struct {
+	Dir string
+
+	// FileOptions is applied to every file.
+	FileOptions DestinationTypeFileOptions
+
+	// DirMode is mode of created directories. If DirMode is 0, 0755 is used.
+	DirMode fs.FileMode
+	DirUid  int
+	DirGid  int
+}
+
+// Write writes files keyed by relative path into the directory.
+// It returns paths newly created by ssmwrap, parents first.
+func (w AtomicDirWriter) Write(files map[string]string) ([]string, error) {
+	names := []string{}
+	for rel := range files {
+		if !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("path %s is not allowed in directory", rel)
+		}
+
+		name := strings.Split(filepath.ToSlash(filepath.Clean(rel)), "/")[0]
+		if strings.HasPrefix(name, "..") {
+			return nil, fmt.Errorf("path %s is reserved", rel)
+		}
+
+		names = append(names, name)
+	}
+
+	names = lo.Uniq(names)
+	sort.Strings(names)
+
+	created, err := mkdirAll(w.Dir, w.dirMode(), w.DirUid, w.DirGid)
+	if err != nil {
+		return created, err
+	}
+
+	snapshot, written, err := w.writeSnapshot(files)
+	if err != nil {
+		return created, err
+	}
+
+	dataLink := filepath.Join(w.Dir, atomicDirDataLink)
+	_, err = os.Lstat(dataLink)
+	dataLinkExisted := err == nil
+
+	if err := w.swap(snapshot); err != nil {
+		os.RemoveAll(snapshot)
+		return created, err
+	}
+
+	created = append(created, written...)
+	if !dataLinkExisted {
+		created = append(created, dataLink)
+	}
+
+	links, err := w.link(names)
+	created = append(created, links...)
+	if err != nil {
+		return created, err
+	}
+
+	w.removeStale(names, filepath.Base(snapshot))
+
+	return created, nil
+}
+
+// writeSnapshot writes files into a new snapshot directory.
+// It returns path of the snapshot, and paths of all directories and files in it including itself.
+func (w AtomicDirWriter) writeSnapshot(files map[string]string) (string, []string, error) {
+	snapshot, err := os.MkdirTemp(w.Dir, time.Now().UTC().Format(atomicDirSnapshotLayout))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	if err := w.chownDir(snapshot); err != nil {
+		os.RemoveAll(snapshot)
+		return "", nil, err
+	}
+
+	written := []string{snapshot}
+
+	rels := lo.Keys(files)
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		path := filepath.Join(snapshot, rel)
+
+		dirs, err := mkdirAll(filepath.Dir(path), w.dirMode(), w.DirUid, w.DirGid)
+		if err != nil {
+			os.RemoveAll(snapshot)
+			return "", nil, err
+		}
+
+		written = append(written, dirs...)
+
+		ex := NewFileExporter(path)
+		if w.FileOptions.Mode != 0 {
+			ex.Mode = w.FileOptions.Mode
+		}
+		if w.FileOptions.Uid != 0 {
+			ex.Uid = w.FileOptions.Uid
+		}
+		if w.FileOptions.Gid != 0 {
+			ex.Gid = w.FileOptions.Gid
+		}
+
+		if err := ex.Export(files[rel]); err != nil {
+			os.RemoveAll(snapshot)
+			return "", nil, err
+		}
+
+		written = append(written, path)
+	}
+
+	return snapshot, written, nil
+}
+
+func (w AtomicDirWriter) dirMode() fs.FileMode {
+	if w.DirMode == 0 {
+		return 0755
+	}
+
+	return w.DirMode
+}
+
+func (w AtomicDirWriter) chownDir(dir string) error {
+	if err := os.Chmod(dir, w.dirMode()); err != nil {
+		return fmt.Errorf("failed to chmod directory %s: %w", dir, err)
+	}
+
+	if w.DirUid != 0 || w.DirGid != 0 {
+		if err := os.Chown(dir, ownerID(w.DirUid), ownerID(w.DirGid)); err != nil {
+			return fmt.Errorf("failed to chown directory %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// swap points `..data` symlink to snapshot atomically.
+func (w AtomicDirWriter) swap(snapshot string) error {
+	tmp := filepath.Join(w.Dir, atomicDirDataLink+"_tmp")
+
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", tmp, err)
+	}
+
+	if err := os.Symlink(filepath.Base(snapshot), tmp); err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(w.Dir, atomicDirDataLink)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to swap %s: %w", atomicDirDataLink, err)
+	}
+
+	return nil
+}
+
+// link creates symlinks of top-level entries through `..data`, and returns paths of newly created ones.
+// Symlinks which already exist, like ones created by a previous run, are kept and not returned.
+func (w AtomicDirWriter) link(names []string) ([]string, error) {
+	links := []string{}
+
+	for _, name := range names {
+		path := filepath.Join(w.Dir, name)
+		target := filepath.Join(atomicDirDataLink, name)
+
+		info, err := os.Lstat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if err := os.Symlink(target, path); err != nil {
+				return links, fmt.Errorf("failed to create symlink %s: %w", path, err)
+			}
+
+			links = append(links, path)
+		case err != nil:
+			return links, fmt.Errorf("failed to stat %s: %w", path, err)
+		case info.Mode()&fs.ModeSymlink == 0:
+			return links, fmt.Errorf("%s already exists and is not managed by ssmwrap", path)
+		default:
+			if dest, _ := os.Readlink(path); dest != target {
+				return links, fmt.Errorf("%s already exists and is not managed by ssmwrap", path)
+			}
+		}
+	}
+
+	return links, nil
+}
+
+// removeStale removes symlinks of entries not in names, and snapshots other than current.
+func (w AtomicDirWriter) removeStale(names []string, current string) {
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		slog.Warn("failed to read directory", slog.String("path", w.Dir), slog.String("error", err.Error()))
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(w.Dir, name)
+
+		switch {
+		case name == atomicDirDataLink || name == current:
+			continue
+		case entry.IsDir() && isAtomicDirSnapshot(name):
+			slog.Debug("removing old snapshot", slog.String("path", path))
+			if err := os.RemoveAll(path); err != nil {
+				slog.Warn("failed to remove old snapshot", slog.String("path", path), slog.String("error", err.Error()))
+			}
+		case entry.Type()&fs.ModeSymlink != 0 && !lo.Contains(names, name):
+			if dest, _ := os.Readlink(path); dest != filepath.Join(atomicDirDataLink, name) {
+				continue
+			}
+
+			slog.Debug("removing stale entry", slog.String("path", path))
+			if err := os.Remove(path); err != nil {
+				slog.Warn("failed to remove stale entry", slog.String("path", path), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func isAtomicDirSnapshot(name string) bool {
+	if len(name) <= len(atomicDirSnapshotLayout) {
+		return false
+	}
+
+	_, err := time.Parse(atomicDirSnapshotLayout, name[:len(atomicDirSnapshotLayout)])
+
+	return err == nil
+}
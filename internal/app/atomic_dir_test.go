@@ -0,0 +1,164 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/samber/lo"
+)
+
+func TestAtomicDirWriterWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	w := AtomicDirWriter{Dir: dir}
+
+	created, err := w.Write(map[string]string{
+		"tls.crt":     "cert1",
+		"ca/root.crt": "root1",
+		"old":         "old",
+	})
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+
+	if created[0] != dir {
+		t.Errorf("directory should be reported as created first: %v", created)
+	}
+
+	if _, err := w.Write(map[string]string{
+		"tls.crt":     "cert2",
+		"ca/root.crt": "root2",
+		"new":         "new",
+	}); err != nil {
+		t.Fatalf("failed to write again: %s", err)
+	}
+
+	for rel, want := range map[string]string{
+		"tls.crt":     "cert2",
+		"ca/root.crt": "root2",
+		"new":         "new",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			t.Fatalf("failed to read %s: %s", rel, err)
+		}
+
+		if string(got) != want {
+			t.Errorf("unexpected content of %s: %s", rel, got)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read directory: %s", err)
+	}
+
+	names := []string{}
+	snapshots := 0
+	for _, entry := range entries {
+		if isAtomicDirSnapshot(entry.Name()) {
+			snapshots++
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	if diff := cmp.Diff([]string{"..data", "ca", "new", "tls.crt"}, names); diff != "" {
+		t.Errorf("entries have diff:\n%s", diff)
+	}
+
+	if snapshots != 1 {
+		t.Errorf("old snapshots should be removed: %d snapshots", snapshots)
+	}
+
+	link, err := os.Readlink(filepath.Join(dir, "tls.crt"))
+	if err != nil {
+		t.Fatalf("entry should be a symlink: %s", err)
+	}
+
+	if link != filepath.Join("..data", "tls.crt") {
+		t.Errorf("unexpected link: %s", link)
+	}
+}
+
+func TestAtomicDirWriterWriteReturnsOnlyCreatedPaths(t *testing.T) {
+	dir := t.TempDir()
+	w := AtomicDirWriter{Dir: dir}
+
+	// prior snapshot written by another run
+	if _, err := w.Write(map[string]string{"tls.crt": "cert1"}); err != nil {
+		t.Fatalf("failed to write prior snapshot: %s", err)
+	}
+
+	created, err := w.Write(map[string]string{
+		"tls.crt": "cert2",
+		"new":     "new",
+	})
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+
+	for _, path := range created {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatalf("failed to get relative path: %s", err)
+		}
+
+		if rel == "." || rel == "..data" || rel == "tls.crt" {
+			t.Errorf("existing path should not be reported as created: %s", path)
+		}
+	}
+
+	if !lo.Contains(created, filepath.Join(dir, "new")) {
+		t.Errorf("new symlink should be reported as created: %v", created)
+	}
+}
+
+func TestAtomicDirWriterWriteReturnsError(t *testing.T) {
+	tests := []struct {
+		title string
+		files map[string]string
+		init  func(dir string) error
+	}{
+		{
+			title: "outside of directory",
+			files: map[string]string{"../escape": "escape"},
+		},
+		{
+			title: "reserved name",
+			files: map[string]string{"..data/file": "file"},
+		},
+		{
+			title: "existing file not managed",
+			files: map[string]string{"file": "file"},
+			init: func(dir string) error {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return err
+				}
+
+				return os.WriteFile(filepath.Join(dir, "file"), []byte("original"), 0644)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "secrets")
+
+			if tt.init != nil {
+				if err := tt.init(dir); err != nil {
+					t.Fatalf("failed to init: %s", err)
+				}
+			}
+
+			if _, err := (AtomicDirWriter{Dir: dir}).Write(tt.files); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// BundleFormat is a format of file which bundles many parameters.
+type BundleFormat string
+
+const (
+	BundleFormatDotenv     BundleFormat = "dotenv"
+	BundleFormatJSON       BundleFormat = "json"
+	BundleFormatYAML       BundleFormat = "yaml"
+	BundleFormatTOML       BundleFormat = "toml"
+	BundleFormatProperties BundleFormat = "properties"
+)
+
+func ParseBundleFormat(s string) (BundleFormat, error) {
+	switch f := BundleFormat(s); f {
+	case BundleFormatDotenv, BundleFormatJSON, BundleFormatYAML, BundleFormatTOML, BundleFormatProperties:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported bundle format: %s", s)
+	}
+}
+
+// Structured reports whether the format can represent nested objects.
+// Flat formats have only `KEY=VALUE` pairs.
+func (f BundleFormat) Structured() bool {
+	switch f {
+	case BundleFormatJSON, BundleFormatYAML, BundleFormatTOML:
+		return true
+	default:
+		return false
+	}
+}
+
+// Bundle is a set of parameters to be written into one file.
+// Keys are paths of nested objects. In flat formats, each key has only one element.
+type Bundle struct {
+	tree map[string]any
+}
+
+func NewBundle() *Bundle {
+	return &Bundle{
+		tree: map[string]any{},
+	}
+}
+
+// Add adds value at key.
+// It returns error if the key conflicts with keys added before.
+func (b *Bundle) Add(key []string, value string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("empty key")
+	}
+
+	node := b.tree
+	for i, k := range key[:len(key)-1] {
+		switch child := node[k].(type) {
+		case nil:
+			next := map[string]any{}
+			node[k] = next
+			node = next
+		case map[string]any:
+			node = child
+		default:
+			return fmt.Errorf("key %s conflicts with %s", strings.Join(key, "."), strings.Join(key[:i+1], "."))
+		}
+	}
+
+	last := key[len(key)-1]
+	if _, ok := node[last]; ok {
+		return fmt.Errorf("duplicated key %s", strings.Join(key, "."))
+	}
+
+	node[last] = value
+
+	return nil
+}
+
+// Encode encodes the bundle in format f.
+func (b Bundle) Encode(f BundleFormat) (string, error) {
+	if !f.Structured() {
+		for k, v := range b.tree {
+			if _, ok := v.(string); !ok {
+				return "", fmt.Errorf("nested key %s is not allowed for %s", k, f)
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	switch f {
+	case BundleFormatDotenv:
+		for _, k := range sortedKeys(b.tree) {
+			fmt.Fprintf(buf, "%s=%s\n", k, dotenvQuote(b.tree[k].(string)))
+		}
+	case BundleFormatProperties:
+		for _, k := range sortedKeys(b.tree) {
+			fmt.Fprintf(buf, "%s=%s\n", propertiesEscape(k, true), propertiesEscape(b.tree[k].(string), false))
+		}
+	case BundleFormatJSON:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(b.tree); err != nil {
+			return "", err
+		}
+	case BundleFormatYAML:
+		writeYAML(buf, b.tree, 0)
+	case BundleFormatTOML:
+		writeTOML(buf, b.tree, nil)
+	default:
+		return "", fmt.Errorf("unsupported bundle format: %s", f)
+	}
+
+	return buf.String(), nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+var dotenvReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`$`, `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// dotenvQuote quotes v by double quotes.
+// `$` is escaped to prevent variable expansion.
+func dotenvQuote(v string) string {
+	return `"` + dotenvReplacer.Replace(v) + `"`
+}
+
+// propertiesEscape escapes s as same as java.util.Properties#store.
+func propertiesEscape(s string, isKey bool) string {
+	b := &strings.Builder{}
+
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		case '\\', '=', ':', '#', '!':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || 0x7e < r {
+				for _, u := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(b, `\u%04X`, u)
+				}
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+var tomlBareKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func writeTOML(buf *bytes.Buffer, tree map[string]any, path []string) {
+	tables := []string{}
+
+	for _, k := range sortedKeys(tree) {
+		switch v := tree[k].(type) {
+		case map[string]any:
+			tables = append(tables, k)
+		case string:
+			fmt.Fprintf(buf, "%s = %s\n", tomlKey(k), tomlQuote(v))
+		}
+	}
+
+	for _, k := range tables {
+		p := append(append([]string{}, path...), k)
+
+		keys := make([]string, len(p))
+		for i, pk := range p {
+			keys[i] = tomlKey(pk)
+		}
+
+		if 0 < buf.Len() {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(buf, "[%s]\n", strings.Join(keys, "."))
+		writeTOML(buf, tree[k].(map[string]any), p)
+	}
+}
+
+func tomlKey(k string) string {
+	if tomlBareKeyRegexp.MatchString(k) {
+		return k
+	}
+
+	return tomlQuote(k)
+}
+
+// tomlQuote quotes v as a TOML basic string.
+func tomlQuote(v string) string {
+	b := &strings.Builder{}
+	b.WriteRune('"')
+
+	for _, r := range v {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	b.WriteRune('"')
+
+	return b.String()
+}
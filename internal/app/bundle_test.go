@@ -0,0 +1,176 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBundleEncodeFlat(t *testing.T) {
+	bundle := NewBundle()
+	for key, value := range map[string]string{
+		"FOO":   "foo",
+		"MULTI": "line1\nline2",
+		"QUOTE": `say "$HOME" \o/`,
+		"SPACE": " a=b:c #!",
+		"UTF8":  "日本",
+	} {
+		if err := bundle.Add([]string{key}, value); err != nil {
+			t.Fatalf("failed to add %s: %s", key, err)
+		}
+	}
+
+	tests := []struct {
+		format BundleFormat
+		want   string
+	}{
+		{
+			format: BundleFormatDotenv,
+			want: `FOO="foo"
+MULTI="line1\nline2"
+QUOTE="say \"\$HOME\" \\o/"
+SPACE=" a=b:c #!"
+UTF8="日本"
+`,
+		},
+		{
+			format: BundleFormatProperties,
+			want: `FOO=foo
+MULTI=line1\nline2
+QUOTE=say "$HOME" \\o/
+SPACE=\ a\=b\:c \#\!
+UTF8=\u65E5\u672C
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := bundle.Encode(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Encode() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBundleEncodeStructured(t *testing.T) {
+	bundle := NewBundle()
+	for _, p := range []struct {
+		key   []string
+		value string
+	}{
+		{key: []string{"NAME"}, value: "app"},
+		{key: []string{"DB", "HOST"}, value: "db.local"},
+		{key: []string{"DB", "PASSWORD"}, value: "p\"a\\ss\nword"},
+		{key: []string{"DB", "REPLICA", "HOST"}, value: "replica.local"},
+		{key: []string{"TRUE"}, value: "yes"},
+	} {
+		if err := bundle.Add(p.key, p.value); err != nil {
+			t.Fatalf("failed to add %v: %s", p.key, err)
+		}
+	}
+
+	tests := []struct {
+		format BundleFormat
+		want   string
+	}{
+		{
+			format: BundleFormatJSON,
+			want: `{
+  "DB": {
+    "HOST": "db.local",
+    "PASSWORD": "p\"a\\ss\nword",
+    "REPLICA": {
+      "HOST": "replica.local"
+    }
+  },
+  "NAME": "app",
+  "TRUE": "yes"
+}
+`,
+		},
+		{
+			format: BundleFormatYAML,
+			want: `DB:
+  HOST: "db.local"
+  PASSWORD: "p\"a\\ss\nword"
+  REPLICA:
+    HOST: "replica.local"
+NAME: "app"
+"TRUE": "yes"
+`,
+		},
+		{
+			format: BundleFormatTOML,
+			want: `NAME = "app"
+TRUE = "yes"
+
+[DB]
+HOST = "db.local"
+PASSWORD = "p\"a\\ss\nword"
+
+[DB.REPLICA]
+HOST = "replica.local"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := bundle.Encode(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Encode() has diff:\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := bundle.Encode(BundleFormatDotenv); err == nil {
+		t.Errorf("nested keys should not be allowed for flat format")
+	}
+}
+
+func TestBundleAddReturnsError(t *testing.T) {
+	tests := []struct {
+		title string
+		keys  [][]string
+	}{
+		{
+			title: "duplicated",
+			keys:  [][]string{{"FOO"}, {"FOO"}},
+		},
+		{
+			title: "value then object",
+			keys:  [][]string{{"DB"}, {"DB", "HOST"}},
+		},
+		{
+			title: "object then value",
+			keys:  [][]string{{"DB", "HOST"}, {"DB"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			bundle := NewBundle()
+
+			var err error
+			for _, key := range tt.keys {
+				if err = bundle.Add(key, "value"); err != nil {
+					break
+				}
+			}
+
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
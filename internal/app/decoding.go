@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Decoding is an encoding of parameter values to be decoded before exporting.
+// Parameter Store holds only strings, so binary values like keystores are stored encoded.
+type Decoding string
+
+const (
+	DecodingNone       Decoding = ""
+	DecodingBase64     Decoding = "base64"
+	DecodingBase64URL  Decoding = "base64url"
+	DecodingHex        Decoding = "hex"
+	DecodingGzipBase64 Decoding = "gzip+base64"
+)
+
+// maxDecompressedSize is a limit of size of value decompressed by DecodingGzipBase64.
+const maxDecompressedSize = 64 << 20
+
+func ParseDecoding(s string) (Decoding, error) {
+	switch d := Decoding(s); d {
+	case DecodingBase64, DecodingBase64URL, DecodingHex, DecodingGzipBase64:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unsupported decoding: %s", s)
+	}
+}
+
+// Decode decodes v. If d is DecodingNone, v is returned as is.
+// Whitespaces in v like line breaks are ignored, and padding of base64 is optional.
+func (d Decoding) Decode(v string) (string, error) {
+	if d == DecodingNone {
+		return v, nil
+	}
+
+	v = strings.Join(strings.Fields(v), "")
+
+	var (
+		b   []byte
+		err error
+	)
+
+	switch d {
+	case DecodingBase64:
+		b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "="))
+	case DecodingBase64URL:
+		b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+	case DecodingHex:
+		b, err = hex.DecodeString(v)
+	case DecodingGzipBase64:
+		b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "="))
+		if err == nil {
+			b, err = gunzip(b)
+		}
+	default:
+		return "", fmt.Errorf("unsupported decoding: %s", d)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to decode value as %s: %w", d, err)
+	}
+
+	return string(b), nil
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed value exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodingDecode(t *testing.T) {
+	binary := "\x00\x01\xfe\xffkey"
+
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(binary))
+	w.Close()
+	gzipped := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		decoding Decoding
+		value    string
+		want     string
+	}{
+		{decoding: DecodingNone, value: "raw value", want: "raw value"},
+		{decoding: DecodingBase64, value: "AAH+/2tleQ==", want: binary},
+		{decoding: DecodingBase64, value: "AAH+/2tleQ", want: binary},
+		{decoding: DecodingBase64, value: "AAH+\n/2tl\r\neQ==\n", want: binary},
+		{decoding: DecodingBase64URL, value: "AAH-_2tleQ", want: binary},
+		{decoding: DecodingHex, value: "0001feff6b6579", want: binary},
+		{decoding: DecodingHex, value: "0001FEFF 6B6579", want: binary},
+		{decoding: DecodingGzipBase64, value: gzipped, want: binary},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.decoding)+" "+tt.value, func(t *testing.T) {
+			got, err := tt.decoding.Decode(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Decode() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodingDecodeReturnsError(t *testing.T) {
+	tests := []struct {
+		decoding Decoding
+		value    string
+	}{
+		{decoding: DecodingBase64, value: "not base64!"},
+		{decoding: DecodingBase64, value: "AAH-_2tleQ"},
+		{decoding: DecodingBase64URL, value: "AAH+/2tleQ"},
+		{decoding: DecodingHex, value: "0g"},
+		{decoding: DecodingHex, value: "000"},
+		{decoding: DecodingGzipBase64, value: "AAH+/2tleQ=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.decoding)+" "+tt.value, func(t *testing.T) {
+			if _, err := tt.decoding.Decode(tt.value); err == nil {
+				t.Errorf("should be error")
+			}
+		})
+	}
+}
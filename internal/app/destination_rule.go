@@ -8,8 +8,11 @@ import (
 type DestinationType string
 
 const (
-	DestinationTypeEnv  DestinationType = "env"
-	DestinationTypeFile DestinationType = "file"
+	DestinationTypeEnv      DestinationType = "env"
+	DestinationTypeFile     DestinationType = "file"
+	DestinationTypeBundle   DestinationType = "bundle"
+	DestinationTypeTemplate DestinationType = "template"
+	DestinationTypeDir      DestinationType = "dir"
 )
 
 type DestinationRule struct {
@@ -18,8 +21,28 @@ type DestinationRule struct {
 	// To is address of destination.
 	To string
 
-	TypeEnvOptions  *DestinationTypeEnvOptions
-	TypeFileOptions *DestinationTypeFileOptions
+	// Decode is an encoding of parameter values decoded before exporting.
+	Decode Decoding
+
+	// Key is a selector of field in JSON values to be exported, like `.tls.cert`. See KeySelector.
+	// If Key is empty, then the whole value is exported.
+	Key string
+
+	// List is a way to export items of StringList parameters.
+	List ListMode
+
+	// ListSeparator is a separator of items joined by ListModeJoin. See ParseSeparator.
+	// If ListSeparator is empty, then " " is used.
+	ListSeparator string
+
+	// KeyOptional is a flag to export empty value instead of failing when the field selected by Key does not exist.
+	KeyOptional bool
+
+	TypeEnvOptions      *DestinationTypeEnvOptions
+	TypeFileOptions     *DestinationTypeFileOptions
+	TypeBundleOptions   *DestinationTypeBundleOptions
+	TypeTemplateOptions *DestinationTypeTemplateOptions
+	TypeDirOptions      *DestinationTypeDirOptions
 }
 
 func (r DestinationRule) String() string {
@@ -30,11 +53,68 @@ func (r DestinationRule) String() string {
 		s += fmt.Sprintf(" (%s %+v)", r.Type, r.TypeEnvOptions)
 	case DestinationTypeFile:
 		s += fmt.Sprintf(" (%s %+v)", r.Type, r.TypeFileOptions)
+	case DestinationTypeBundle:
+		s += fmt.Sprintf(" (%s %+v)", r.Type, r.TypeBundleOptions)
+	case DestinationTypeTemplate:
+		s += fmt.Sprintf(" (%s %+v)", r.Type, r.TypeTemplateOptions)
+	case DestinationTypeDir:
+		s += fmt.Sprintf(" (%s %+v)", r.Type, r.TypeDirOptions)
+	}
+
+	if r.Decode != DecodingNone {
+		s += " decode=" + string(r.Decode)
+	}
+
+	if r.Key != "" {
+		s += " key=" + r.Key
+	}
+
+	if r.List != ListModeNone {
+		s += " list=" + string(r.List)
 	}
 
 	return s
 }
 
+// WritesFile reports whether the destination is a file.
+func (r DestinationRule) WritesFile() bool {
+	switch r.Type {
+	case DestinationTypeFile, DestinationTypeBundle, DestinationTypeTemplate, DestinationTypeDir:
+		return true
+	default:
+		return false
+	}
+}
+
+// FileOptions returns options for the file of destination.
+// It returns nil if the destination is not a file.
+func (r DestinationRule) FileOptions() *DestinationTypeFileOptions {
+	switch r.Type {
+	case DestinationTypeFile:
+		return r.TypeFileOptions
+	case DestinationTypeBundle:
+		if r.TypeBundleOptions == nil {
+			return nil
+		}
+
+		return &r.TypeBundleOptions.DestinationTypeFileOptions
+	case DestinationTypeTemplate:
+		if r.TypeTemplateOptions == nil {
+			return nil
+		}
+
+		return &r.TypeTemplateOptions.DestinationTypeFileOptions
+	case DestinationTypeDir:
+		if r.TypeDirOptions == nil {
+			return nil
+		}
+
+		return &r.TypeDirOptions.DestinationTypeFileOptions
+	default:
+		return nil
+	}
+}
+
 type DestinationTypeEnvOptions struct {
 	// Prefix is a prefix for environment variable.
 	// For example, if Prefix is PREFIX, then the environment variable name will be PREFIX_NAME.
@@ -44,10 +124,51 @@ type DestinationTypeEnvOptions struct {
 	// For example, if EntirePath is true and the path is /a/b/c, then the environment variable name will be A_B_C.
 	// If EntirePath is false, then the environment variable name will be C.
 	EntirePath bool
+
+	// Expand is a format of values to be expanded into many environment variables.
+	// For example, if Expand is json and the value of /a/db is {"user":"app"}, then DB_USER=app is exported.
+	Expand Expansion
+
+	// ExpandSeparator is a separator of names of expanded environment variables.
+	// If ExpandSeparator is empty, then "_" is used.
+	ExpandSeparator string
+
+	// Join is a flag to join values of all parameters into one environment variable named by DestinationRule.To.
+	Join bool
+
+	// JoinSeparator is a separator of joined values.
+	// If JoinSeparator is empty, then " " is used.
+	JoinSeparator string
+
+	// JoinOrder is an order of joined values.
+	// If JoinOrder is empty, then values are sorted by name.
+	JoinOrder JoinOrder
 }
 
 func (o DestinationTypeEnvOptions) String() string {
-	return fmt.Sprintf("prefix=%s,entirepath=%t", o.Prefix, o.EntirePath)
+	s := fmt.Sprintf("prefix=%s,entirepath=%t", o.Prefix, o.EntirePath)
+
+	if o.Expand != ExpansionNone {
+		s += ",expand=" + string(o.Expand)
+	}
+
+	if o.ExpandSeparator != "" {
+		s += ",expandsep=" + o.ExpandSeparator
+	}
+
+	if o.Join {
+		s += ",join=true"
+	}
+
+	if o.JoinSeparator != "" {
+		s += ",joinsep=" + FormatSeparator(o.JoinSeparator)
+	}
+
+	if o.JoinOrder != "" {
+		s += ",joinorder=" + string(o.JoinOrder)
+	}
+
+	return s
 }
 
 type DestinationTypeFileOptions struct {
@@ -62,8 +183,105 @@ type DestinationTypeFileOptions struct {
 	// Gid is a group id of exported file.
 	// If Gid is 0, then the default group id is used defined in FileExporter.
 	Gid int
+
+	// Cleanup is a flag to remove exported file when the command exits in supervisor mode.
+	// If the file existed before exporting, it will be restored instead.
+	Cleanup bool
+
+	// FollowSymlink is a flag to write into the target of symlink at the destination.
+	// If FollowSymlink is false, exporting fails when the destination is a symlink.
+	FollowSymlink bool
+
+	// Mkdir is a flag to create missing parent directories of exported file.
+	Mkdir bool
+
+	// DirMode is a file mode of created directories.
+	// If DirMode is 0, then 0755 is used.
+	DirMode fs.FileMode
+
+	// DirUid is a user id of created directories.
+	// If DirUid is 0, then the current user id is used.
+	DirUid int
+
+	// DirGid is a group id of created directories.
+	// If DirGid is 0, then the current group id is used.
+	DirGid int
 }
 
 func (o DestinationTypeFileOptions) String() string {
-	return fmt.Sprintf("mode=%04o,uid=%d,gid=%d", o.Mode, o.Uid, o.Gid)
+	s := fmt.Sprintf("mode=%04o,uid=%d,gid=%d", o.Mode, o.Uid, o.Gid)
+
+	if o.Cleanup {
+		s += ",cleanup=true"
+	}
+
+	if o.FollowSymlink {
+		s += ",followsymlink=true"
+	}
+
+	if o.Mkdir {
+		s += ",mkdir=true"
+	}
+
+	if o.DirMode != 0 {
+		s += fmt.Sprintf(",dirmode=%04o", o.DirMode)
+	}
+
+	if o.DirUid != 0 {
+		s += fmt.Sprintf(",diruid=%d", o.DirUid)
+	}
+
+	if o.DirGid != 0 {
+		s += fmt.Sprintf(",dirgid=%d", o.DirGid)
+	}
+
+	return s
+}
+
+// DestinationTypeBundleOptions is options to write many parameters into one file.
+// Keys in the file are named by DestinationTypeEnvOptions as same as environment variables,
+// and the file is written by DestinationTypeFileOptions.
+type DestinationTypeBundleOptions struct {
+	// Format is a format of the file.
+	Format BundleFormat
+
+	DestinationTypeEnvOptions
+	DestinationTypeFileOptions
+}
+
+func (o DestinationTypeBundleOptions) String() string {
+	s := "format=" + string(o.Format)
+
+	if o.Prefix != "" {
+		s += ",prefix=" + o.Prefix
+	}
+
+	if o.EntirePath {
+		s += ",entirepath=true"
+	}
+
+	return s + "," + o.DestinationTypeFileOptions.String()
+}
+
+// DestinationTypeTemplateOptions is options to render a template file with parameters.
+// The rendered file is written by DestinationTypeFileOptions.
+type DestinationTypeTemplateOptions struct {
+	// Src is a path of Go text/template file.
+	Src string
+
+	DestinationTypeFileOptions
+}
+
+func (o DestinationTypeTemplateOptions) String() string {
+	return "src=" + o.Src + "," + o.DestinationTypeFileOptions.String()
+}
+
+// DestinationTypeDirOptions is options to write each parameter into its own file under a directory.
+// Files are written by DestinationTypeFileOptions, and missing directories are always created.
+type DestinationTypeDirOptions struct {
+	DestinationTypeFileOptions
+}
+
+func (o DestinationTypeDirOptions) String() string {
+	return o.DestinationTypeFileOptions.String()
 }
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// mkdirAll creates directory at path with its parents like os.MkdirAll,
+// and returns paths of created directories, parents first.
+// mode is set regardless of umask. If uid or gid is 0, the owner is not changed.
+func mkdirAll(path string, mode fs.FileMode, uid, gid int) ([]string, error) {
+	missing := []string{}
+
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return nil, fmt.Errorf("%s is not a directory", dir)
+			}
+
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to stat directory %s: %w", dir, err)
+		}
+
+		missing = append([]string{dir}, missing...)
+
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	created := []string{}
+
+	for _, dir := range missing {
+		if err := os.Mkdir(dir, mode); err != nil {
+			return created, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+
+		created = append(created, dir)
+
+		if err := os.Chmod(dir, mode); err != nil {
+			return created, fmt.Errorf("failed to chmod directory %s: %w", dir, err)
+		}
+
+		if uid != 0 || gid != 0 {
+			if err := os.Chown(dir, ownerID(uid), ownerID(gid)); err != nil {
+				return created, fmt.Errorf("failed to chown directory %s: %w", dir, err)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// ownerID converts id for os.Chown, which keeps the owner by -1 instead of 0.
+func ownerID(id int) int {
+	if id == 0 {
+		return -1
+	}
+
+	return id
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMkdirAll(t *testing.T) {
+	root := t.TempDir()
+
+	// mode should not be affected by umask
+	defer syscall.Umask(syscall.Umask(0077))
+
+	created, err := mkdirAll(filepath.Join(root, "a", "b", "c"), 0750, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		filepath.Join(root, "a"),
+		filepath.Join(root, "a", "b"),
+		filepath.Join(root, "a", "b", "c"),
+	}
+
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Errorf("created directories have diff:\n%s", diff)
+	}
+
+	for _, dir := range created {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %s", dir, err)
+		}
+
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("unexpected mode of %s: %04o", dir, info.Mode().Perm())
+		}
+	}
+
+	created, err = mkdirAll(filepath.Join(root, "a", "b", "d"), 0750, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff([]string{filepath.Join(root, "a", "b", "d")}, created); diff != "" {
+		t.Errorf("created directories have diff:\n%s", diff)
+	}
+}
+
+func TestMkdirAllReturnsErrorForFile(t *testing.T) {
+	root := t.TempDir()
+
+	file := filepath.Join(root, "file")
+	if err := os.WriteFile(file, []byte("file"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	if _, err := mkdirAll(filepath.Join(file, "dir"), 0755, 0, 0); err == nil {
+		t.Errorf("expected error")
+	}
+}
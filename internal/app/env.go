@@ -0,0 +1,42 @@
+package app
+
+import (
+	"sort"
+	"strings"
+)
+
+// Env is a set of environment variables.
+type Env map[string]string
+
+// NewEnv creates Env from environ in form of `KEY=VALUE` like os.Environ().
+func NewEnv(environ []string) Env {
+	env := make(Env, len(environ))
+
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		env[parts[0]] = parts[1]
+	}
+
+	return env
+}
+
+// Environ returns variables in form of `KEY=VALUE` sorted by name.
+func (e Env) Environ() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	environ := make([]string, 0, len(names))
+	for _, name := range names {
+		environ = append(environ, name+"="+e[name])
+	}
+
+	return environ
+}
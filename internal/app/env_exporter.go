@@ -1,14 +1,21 @@
 package app
 
-import "os"
+import (
+	"fmt"
+	"strings"
+)
 
 type EnvExporter struct {
 	Name string
+
+	// Env is a destination of exported value.
+	Env Env
 }
 
-func NewEnvExporter(name string) *EnvExporter {
+func NewEnvExporter(name string, env Env) *EnvExporter {
 	return &EnvExporter{
 		Name: name,
+		Env:  env,
 	}
 }
 
@@ -17,5 +24,15 @@ func (e EnvExporter) Address() string {
 }
 
 func (e EnvExporter) Export(value string) error {
-	return os.Setenv(e.Name, value)
+	if e.Name == "" || strings.ContainsAny(e.Name, "=\x00") {
+		return fmt.Errorf("invalid environment variable name %q", e.Name)
+	}
+
+	if strings.Contains(value, "\x00") {
+		return fmt.Errorf("value for environment variable %s contains NUL character", e.Name)
+	}
+
+	e.Env[e.Name] = value
+
+	return nil
 }
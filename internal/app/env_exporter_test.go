@@ -1,7 +1,6 @@
 package app
 
 import (
-	"os"
 	"strings"
 	"testing"
 )
@@ -9,23 +8,27 @@ import (
 func TestEnvExporterExportSuccess(t *testing.T) {
 	tests := []struct {
 		title string
-		init  func() *EnvExporter
+		init  func(env Env) *EnvExporter
 	}{
 		{
 			title: "normal",
-			init: func() *EnvExporter {
-				return NewEnvExporter("TEST")
+			init: func(env Env) *EnvExporter {
+				return NewEnvExporter("TEST", env)
+			},
+		},
+		{
+			title: "multiline\nvalue",
+			init: func(env Env) *EnvExporter {
+				return NewEnvExporter("TEST", env)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.title, func(t *testing.T) {
-			cleaner := EnvCleaner{}
-			cleaner.Clean()
-			defer cleaner.Restore()
+			env := Env{}
 
-			ex := tt.init()
+			ex := tt.init(env)
 			if ex == nil {
 				t.Errorf("failed to generate EnvExporter")
 			}
@@ -35,8 +38,8 @@ func TestEnvExporterExportSuccess(t *testing.T) {
 				t.Errorf("failed to export: %s", err)
 			}
 
-			if env := os.Getenv(ex.Name); env != value {
-				t.Errorf("unexpected env %s=%s (expected %s)", ex.Name, env, value)
+			if v := env[ex.Name]; v != value {
+				t.Errorf("unexpected env %s=%s (expected %s)", ex.Name, v, value)
 			}
 		})
 	}
@@ -45,38 +48,44 @@ func TestEnvExporterExportSuccess(t *testing.T) {
 func TestEnvExporterExportReturnsError(t *testing.T) {
 	tests := []struct {
 		title string
-		init  func() *EnvExporter
+		init  func(env Env) *EnvExporter
+		value string
 		err   string
 	}{
 		{
 			title: "name contains `=`",
-			init: func() *EnvExporter {
-				return NewEnvExporter("LEFT=RIGHT")
+			init: func(env Env) *EnvExporter {
+				return NewEnvExporter("LEFT=RIGHT", env)
 			},
-			err: "invalid argument",
+			err: "invalid environment variable name",
 		},
 		{
 			title: "name is empty string",
-			init: func() *EnvExporter {
-				return NewEnvExporter("")
+			init: func(env Env) *EnvExporter {
+				return NewEnvExporter("", env)
 			},
-			err: "invalid argument",
+			err: "invalid environment variable name",
+		},
+		{
+			title: "value contains NUL",
+			init: func(env Env) *EnvExporter {
+				return NewEnvExporter("TEST", env)
+			},
+			value: "foo\x00bar",
+			err:   "contains NUL character",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.title, func(t *testing.T) {
-			cleaner := EnvCleaner{}
-			cleaner.Clean()
-			defer cleaner.Restore()
+			env := Env{}
 
-			ex := tt.init()
+			ex := tt.init(env)
 			if ex == nil {
 				t.Errorf("failed to generate EnvExporter")
 			}
 
-			value := tt.title
-			err := ex.Export(value)
+			err := ex.Export(tt.value)
 			if err == nil {
 				t.Fatal("expected error")
 			}
@@ -84,6 +93,10 @@ func TestEnvExporterExportReturnsError(t *testing.T) {
 			if !strings.Contains(err.Error(), tt.err) {
 				t.Errorf("unexpected error: %s", err)
 			}
+
+			if len(env) != 0 {
+				t.Errorf("env should not be changed: %v", env)
+			}
 		})
 	}
 }
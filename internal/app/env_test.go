@@ -0,0 +1,26 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnvEnviron(t *testing.T) {
+	env := NewEnv([]string{
+		"FOO=foo",
+		"BAR=bar=baz",
+		"MULTI=line1\nline2",
+		"INVALID",
+	})
+
+	want := []string{
+		"BAR=bar=baz",
+		"FOO=foo",
+		"MULTI=line1\nline2",
+	}
+
+	if diff := cmp.Diff(want, env.Environ()); diff != "" {
+		t.Errorf("Environ() has diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+)
+
+// AWSConfigError reports failure of loading AWS configuration or credentials.
+type AWSConfigError struct {
+	Err error
+}
+
+func (e AWSConfigError) Error() string {
+	return fmt.Sprintf("failed to load aws config: %s", e.Err)
+}
+
+func (e AWSConfigError) Unwrap() error {
+	return e.Err
+}
+
+// FetchError reports failure of fetching parameters from SSM.
+type FetchError struct {
+	Err error
+}
+
+func (e FetchError) Error() string {
+	return fmt.Sprintf("failed to fetch parameters: %s", e.Err)
+}
+
+func (e FetchError) Unwrap() error {
+	return e.Err
+}
+
+// ParameterNotFoundError reports that a required parameter does not exist.
+type ParameterNotFoundError struct {
+	Path string
+}
+
+func (e ParameterNotFoundError) Error() string {
+	return fmt.Sprintf("parameter not found: %s", e.Path)
+}
+
+// DestinationError reports failure of exporting a parameter to its destination.
+type DestinationError struct {
+	// Address is address of destination, e.g. name of environment variable or path of file.
+	Address string
+
+	Err error
+}
+
+func (e DestinationError) Error() string {
+	return fmt.Sprintf("failed to export to %s: %s", e.Address, e.Err)
+}
+
+func (e DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError reports that the command can not be executed.
+type CommandError struct {
+	Command string
+	Err     error
+}
+
+func (e CommandError) Error() string {
+	return fmt.Sprintf("command is not executable %s: %s", e.Command, e.Err)
+}
+
+func (e CommandError) Unwrap() error {
+	return e.Err
+}
+
+// NotFound reports whether the command is not found.
+func (e CommandError) NotFound() bool {
+	return errors.Is(e.Err, exec.ErrNotFound) || errors.Is(e.Err, fs.ErrNotExist)
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Expansion is a format of parameter values to be expanded into many environment variables.
+type Expansion string
+
+const (
+	ExpansionNone Expansion = ""
+	ExpansionJSON Expansion = "json"
+)
+
+// defaultExpandSeparator is a separator of names of expanded environment variables.
+const defaultExpandSeparator = "_"
+
+func ParseExpansion(s string) (Expansion, error) {
+	switch e := Expansion(s); e {
+	case ExpansionJSON:
+		return e, nil
+	default:
+		return "", fmt.Errorf("unsupported expansion: %s", s)
+	}
+}
+
+// Expand expands v into values keyed by key names.
+// v must be a JSON object. Keys of nested objects are joined by sep.
+// Strings are expanded as is, and other values like numbers and arrays are expanded as JSON.
+func (e Expansion) Expand(v string, sep string) (map[string]string, error) {
+	if e != ExpansionJSON {
+		return nil, fmt.Errorf("unsupported expansion: %s", e)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(v)))
+	dec.UseNumber()
+
+	var parsed any
+	if err := dec.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse value as JSON: %w", err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("failed to parse value as JSON: unexpected data after top-level value")
+	}
+
+	obj, ok := parsed.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("value is not a JSON object but %s", jsonTypeName(parsed))
+	}
+
+	expanded := map[string]string{}
+	if err := flattenJSON(expanded, "", obj, sep); err != nil {
+		return nil, err
+	}
+
+	return expanded, nil
+}
+
+func flattenJSON(dst map[string]string, prefix string, obj map[string]any, sep string) error {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+
+		var s string
+
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenJSON(dst, key, v, sep); err != nil {
+				return err
+			}
+
+			continue
+		case string:
+			s = v
+		case nil:
+			s = ""
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to encode value of key %s: %w", key, err)
+			}
+
+			s = string(b)
+		}
+
+		if _, ok := dst[key]; ok {
+			return fmt.Errorf("key %s conflicts with another key", key)
+		}
+
+		dst[key] = s
+	}
+
+	return nil
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpansionExpand(t *testing.T) {
+	tests := []struct {
+		title string
+		value string
+		sep   string
+		want  map[string]string
+	}{
+		{
+			title: "flat",
+			value: `{"user":"app","password":"p@ss\nword"}`,
+			sep:   "_",
+			want: map[string]string{
+				"user":     "app",
+				"password": "p@ss\nword",
+			},
+		},
+		{
+			title: "nested",
+			value: `{"db":{"host":"db.local","replica":{"host":"replica.local"}}}`,
+			sep:   "__",
+			want: map[string]string{
+				"db__host":          "db.local",
+				"db__replica__host": "replica.local",
+			},
+		},
+		{
+			title: "non-string values",
+			value: `{"port":5432,"ratio":0.10,"tls":true,"hosts":["a","b"],"none":null,"empty":{}}`,
+			sep:   "_",
+			want: map[string]string{
+				"port":  "5432",
+				"ratio": "0.10",
+				"tls":   "true",
+				"hosts": `["a","b"]`,
+				"none":  "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got, err := ExpansionJSON.Expand(tt.value, tt.sep)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Expand() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExpansionExpandReturnsError(t *testing.T) {
+	tests := []struct {
+		title string
+		value string
+	}{
+		{title: "not json", value: "user=app"},
+		{title: "array", value: `["a","b"]`},
+		{title: "string", value: `"value"`},
+		{title: "trailing data", value: `{"a":"b"} {"c":"d"}`},
+		{title: "conflict", value: `{"a_b":"1","a":{"b":"2"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if _, err := ExpansionJSON.Expand(tt.value, "_"); err == nil {
+				t.Errorf("should be error")
+			}
+		})
+	}
+}
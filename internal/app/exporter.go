@@ -4,3 +4,24 @@ type Exporter interface {
 	Address() string
 	Export(value string) error
 }
+
+// Exported holds destinations of parameters exported by rules.
+type Exported struct {
+	// Env is exported environment variables.
+	Env Env
+
+	// Files is paths of exported files.
+	Files []string
+
+	// Created is paths of files, directories and symlinks newly created by exporting, parents first.
+	// They are removed on cleanup, while files in Files may be restored from backup instead.
+	Created []string
+}
+
+func NewExported() *Exported {
+	return &Exported{
+		Env:     Env{},
+		Files:   []string{},
+		Created: []string{},
+	}
+}
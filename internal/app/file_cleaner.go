@@ -0,0 +1,218 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+)
+
+// FileCleaner removes files written by ssmwrap.
+// Files that existed before ssmwrap wrote them are restored from backup instead.
+// Directories are removed only if they did not exist before and are empty.
+type FileCleaner struct {
+	paths   []string
+	backups map[string]*fileBackup
+}
+
+type fileBackup struct {
+	// dir is true if the path is an existing directory, which is kept as is.
+	dir bool
+
+	// path is the file to be restored.
+	// If the tracked path is a symlink, path is the target of it at the time of backup.
+	path string
+
+	content []byte
+	mode    fs.FileMode
+	uid     int
+	gid     int
+}
+
+func NewFileCleaner() *FileCleaner {
+	return &FileCleaner{
+		backups: map[string]*fileBackup{},
+	}
+}
+
+// Track registers path as a target of cleaning up.
+// It must be called before the file is written.
+// If the file already exists, its content is backed up to be restored by Clean.
+// Tracking the same path again does nothing.
+func (c *FileCleaner) Track(path string) error {
+	if _, ok := c.backups[path]; ok {
+		return nil
+	}
+
+	backup, err := c.backup(path)
+	if err != nil {
+		return err
+	}
+
+	c.paths = append(c.paths, path)
+	c.backups[path] = backup
+
+	return nil
+}
+
+// TrackCreated registers path newly created by ssmwrap as a target of cleaning up.
+// Unlike Track, it can be called after the file is written, and the file is always removed by Clean.
+func (c *FileCleaner) TrackCreated(path string) {
+	if _, ok := c.backups[path]; ok {
+		return
+	}
+
+	c.paths = append(c.paths, path)
+	c.backups[path] = nil
+}
+
+func (c FileCleaner) backup(path string) (*fileBackup, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		return &fileBackup{dir: true}, nil
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file %s: %w", path, err)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backup file %s: %w", path, err)
+	}
+
+	backup := &fileBackup{
+		path:    target,
+		content: content,
+		mode:    info.Mode().Perm(),
+		uid:     -1,
+		gid:     -1,
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		backup.uid = int(stat.Uid)
+		backup.gid = int(stat.Gid)
+	}
+
+	return backup, nil
+}
+
+// Clean removes tracked files, or restores them from backup if they existed before.
+// Files to be removed are overwritten with zeros before removing.
+// Directories are removed after all files, deepest first.
+func (c *FileCleaner) Clean() error {
+	errs := []error{}
+	dirs := []string{}
+
+	for i := len(c.paths) - 1; 0 <= i; i-- {
+		path := c.paths[i]
+
+		switch backup := c.backups[path]; {
+		case backup == nil:
+			if info, err := os.Lstat(path); err == nil && info.IsDir() {
+				dirs = append(dirs, path)
+				continue
+			}
+
+			slog.Debug("removing file", slog.String("path", path))
+			errs = append(errs, c.remove(path))
+		case backup.dir:
+			// existing directory is kept as is
+		default:
+			slog.Debug("restoring file", slog.String("path", path))
+			errs = append(errs, c.restore(path, backup))
+		}
+	}
+
+	// a child path is always longer than its parent
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+
+	for _, dir := range dirs {
+		slog.Debug("removing directory", slog.String("path", dir))
+		errs = append(errs, c.remove(dir))
+	}
+
+	c.paths = nil
+	c.backups = map[string]*fileBackup{}
+
+	return errors.Join(errs...)
+}
+
+// restore writes backup back atomically with its original mode and owner by FileExporter.
+// A symlink placed at the path after backup is refused.
+func (c FileCleaner) restore(path string, backup *fileBackup) error {
+	ex := NewFileExporter(backup.path)
+	ex.Mode = backup.mode
+	ex.Uid = backup.uid
+	ex.Gid = backup.gid
+
+	if err := ex.Export(string(backup.content)); err != nil {
+		return fmt.Errorf("failed to restore file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c FileCleaner) remove(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		err := os.Remove(path)
+		if errors.Is(err, syscall.ENOTEMPTY) || errors.Is(err, syscall.EEXIST) {
+			slog.Warn("directory is not empty, kept as is", slog.String("path", path))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove directory %s: %w", path, err)
+		}
+
+		return nil
+	}
+
+	if info.Mode().IsRegular() {
+		if err := c.shred(path, info.Size()); err != nil {
+			slog.Warn("failed to shred file", slog.String("path", path), slog.String("error", err.Error()))
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// shred overwrites content of the file with zeros.
+func (c FileCleaner) shred(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(make([]byte, size)); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileCleanerClean(t *testing.T) {
+	dir := t.TempDir()
+
+	created := filepath.Join(dir, "created")
+	existed := filepath.Join(dir, "existed")
+
+	if err := os.WriteFile(existed, []byte("original"), 0600); err != nil {
+		t.Fatalf("failed to prepare file: %s", err)
+	}
+
+	cleaner := NewFileCleaner()
+
+	for _, path := range []string{created, existed, created} {
+		if err := cleaner.Track(path); err != nil {
+			t.Fatalf("failed to track %s: %s", path, err)
+		}
+
+		if err := os.WriteFile(path, []byte("secret"), 0644); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+
+	if err := cleaner.Clean(); err != nil {
+		t.Fatalf("failed to clean: %s", err)
+	}
+
+	if _, err := os.Stat(created); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("file %s should be removed: %v", created, err)
+	}
+
+	body, err := os.ReadFile(existed)
+	if err != nil {
+		t.Fatalf("failed to read restored file: %s", err)
+	}
+
+	if string(body) != "original" {
+		t.Errorf("unexpected body of restored file: %s", body)
+	}
+
+	info, err := os.Stat(existed)
+	if err != nil {
+		t.Fatalf("failed to stat restored file: %s", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode of restored file: %04o", info.Mode().Perm())
+	}
+}
+
+func TestFileCleanerCleanDirectories(t *testing.T) {
+	root := t.TempDir()
+
+	created := filepath.Join(root, "created")
+	nonEmpty := filepath.Join(root, "non-empty")
+	file := filepath.Join(created, "file")
+
+	cleaner := NewFileCleaner()
+
+	for _, path := range []string{root, created, nonEmpty, file} {
+		if err := cleaner.Track(path); err != nil {
+			t.Fatalf("failed to track %s: %s", path, err)
+		}
+	}
+
+	for _, dir := range []string{created, nonEmpty} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("failed to create directory: %s", err)
+		}
+	}
+
+	if err := os.WriteFile(file, []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	// written by others
+	if err := os.WriteFile(filepath.Join(nonEmpty, "other"), []byte("other"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	if err := cleaner.Clean(); err != nil {
+		t.Fatalf("failed to clean: %s", err)
+	}
+
+	if _, err := os.Stat(created); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("directory %s should be removed: %v", created, err)
+	}
+
+	for _, dir := range []string{root, nonEmpty} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("directory %s should be kept: %s", dir, err)
+		}
+	}
+}
+
+func TestFileCleanerCleanCreatedDirectoriesAfterFiles(t *testing.T) {
+	root := t.TempDir()
+
+	parent := filepath.Join(root, "a")
+	child := filepath.Join(parent, "b")
+	file := filepath.Join(child, "file")
+
+	cleaner := NewFileCleaner()
+
+	// file is tracked before its parents are created, as same as `mkdir=true`
+	if err := cleaner.Track(file); err != nil {
+		t.Fatalf("failed to track %s: %s", file, err)
+	}
+
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+
+	cleaner.TrackCreated(parent)
+	cleaner.TrackCreated(child)
+
+	if err := os.WriteFile(file, []byte("secret"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+
+	if err := cleaner.Clean(); err != nil {
+		t.Fatalf("failed to clean: %s", err)
+	}
+
+	if _, err := os.Stat(parent); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("directory %s should be removed: %v", parent, err)
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("directory %s should be kept: %s", root, err)
+	}
+}
+
+func TestFileCleanerCleanRefusesSymlinkOnRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	existed := filepath.Join(dir, "existed")
+	victim := filepath.Join(dir, "victim")
+
+	for _, path := range []string{existed, victim} {
+		if err := os.WriteFile(path, []byte(filepath.Base(path)), 0600); err != nil {
+			t.Fatalf("failed to prepare file: %s", err)
+		}
+	}
+
+	cleaner := NewFileCleaner()
+
+	if err := cleaner.Track(existed); err != nil {
+		t.Fatalf("failed to track %s: %s", existed, err)
+	}
+
+	// symlink is planted while the command is running
+	if err := os.Remove(existed); err != nil {
+		t.Fatalf("failed to remove file: %s", err)
+	}
+
+	if err := os.Symlink(victim, existed); err != nil {
+		t.Fatalf("failed to create symlink: %s", err)
+	}
+
+	if err := cleaner.Clean(); err == nil {
+		t.Errorf("expected error")
+	}
+
+	body, err := os.ReadFile(victim)
+	if err != nil {
+		t.Fatalf("failed to read file: %s", err)
+	}
+
+	if string(body) != "victim" {
+		t.Errorf("target of symlink should not be overwritten: %s", body)
+	}
+}
@@ -1,16 +1,28 @@
 package app
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"syscall"
 )
 
+// FileExporter writes a value into a file atomically.
+// The value is written into a temporary file in the same directory with final mode and owner,
+// and then the temporary file is renamed to the destination.
 type FileExporter struct {
 	Path string
 	Mode fs.FileMode
 	Uid  int
 	Gid  int
+
+	// FollowSymlink allows to write into the target of symlink at Path.
+	// If FollowSymlink is false, Export fails when Path is a symlink.
+	FollowSymlink bool
 }
 
 func NewFileExporter(path string) *FileExporter {
@@ -26,12 +38,10 @@ func (e FileExporter) Address() string {
 	return e.Path
 }
 
+// Export writes v into the file.
+// If the file already has the same content, it is not rewritten to keep its mtime,
+// but its mode and owner are still updated.
 func (e FileExporter) Export(v string) error {
-	err := os.WriteFile(e.Path, []byte(v), e.Mode)
-	if err != nil {
-		return fmt.Errorf("failed to write to file %s: %w", e.Path, err)
-	}
-
 	uid := e.Uid
 	if uid == 0 {
 		uid = os.Getuid()
@@ -42,9 +52,147 @@ func (e FileExporter) Export(v string) error {
 		gid = os.Getgid()
 	}
 
-	err = os.Chown(e.Path, uid, gid)
+	path, err := e.resolve()
+	if err != nil {
+		return err
+	}
+
+	unchanged, err := e.unchanged(path, v)
+	if err != nil {
+		return err
+	}
+
+	if unchanged {
+		slog.Debug("file is unchanged", slog.String("path", path))
+
+		if err := os.Chmod(path, e.Mode); err != nil {
+			return fmt.Errorf("failed to chmod file %s: %w", path, err)
+		}
+
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to chown file %s: %w", path, err)
+		}
+
+		return nil
+	}
+
+	return e.write(path, v, uid, gid)
+}
+
+// resolve returns path to write into.
+// If the file is a symlink, it returns the target if FollowSymlink is true, or error otherwise.
+func (e FileExporter) resolve() (string, error) {
+	info, err := os.Lstat(e.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return e.Path, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write to file %s: %w", e.Path, err)
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if !e.FollowSymlink {
+			return "", fmt.Errorf("failed to write to file %s: refused to follow symlink", e.Path)
+		}
+
+		target, err := filepath.EvalSymlinks(e.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// dangling symlink
+			target, err = os.Readlink(e.Path)
+			if err == nil && !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(e.Path), target)
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve symlink %s: %w", e.Path, err)
+		}
+
+		return target, nil
+	}
+
+	if info.IsDir() {
+		return "", fmt.Errorf("failed to write to file %s: is a directory", e.Path)
+	}
+
+	return e.Path, nil
+}
+
+// unchanged reports whether the file at path already has content v.
+func (e FileExporter) unchanged(path string, v string) (bool, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+
+	if !info.Mode().IsRegular() || info.Size() != int64(len(v)) {
+		return false, nil
+	}
+
+	current, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to chown file %s: %w", e.Path, err)
+		return false, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	return bytes.Equal(current, []byte(v)), nil
+}
+
+// write writes v into a temporary file, and renames it to path.
+func (e FileExporter) write(path string, v string, uid, gid int) error {
+	dir := filepath.Dir(path)
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp")
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to write to file %s: directory %s does not exist", path, dir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", path, err)
+	}
+
+	tmp := f.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	// set mode and owner before writing content, so that the content is never exposed to others
+	if err := f.Chmod(e.Mode); err != nil {
+		return fmt.Errorf("failed to chmod file %s: %w", path, err)
+	}
+
+	if err := f.Chown(uid, gid); err != nil {
+		return fmt.Errorf("failed to chown file %s: %w", path, err)
+	}
+
+	if _, err := f.WriteString(v); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", path, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file %s: %w", path, err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", path, err)
+	}
+
+	committed = true
+
+	// sync directory to persist the rename
+	if d, err := os.Open(dir); err == nil {
+		if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+			slog.Warn("failed to sync directory", slog.String("path", dir), slog.String("error", err.Error()))
+		}
+		d.Close()
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFileExporterExportSuccess(t *testing.T) {
@@ -86,3 +87,98 @@ func TestFileExporterExportFailedToWrite(t *testing.T) {
 		t.Errorf("unexpected error: %s", err)
 	}
 }
+
+func TestFileExporterExportSymlink(t *testing.T) {
+	dir := t.TempDir()
+
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatalf("failed to write target: %s", err)
+	}
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("failed to create symlink: %s", err)
+	}
+
+	ex := NewFileExporter(link)
+	if err := ex.Export("refused"); err == nil {
+		t.Fatalf("writing to symlink should be refused")
+	}
+
+	ex.FollowSymlink = true
+	if err := ex.Export("followed"); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	body, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("failed to read target: %s", err)
+	}
+
+	if string(body) != "followed" {
+		t.Errorf("unexpected body of target: %s", body)
+	}
+
+	if dest, err := os.Readlink(link); err != nil || dest != target {
+		t.Errorf("symlink should be kept: %s, %v", dest, err)
+	}
+}
+
+func TestFileExporterExportAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out")
+
+	ex := NewFileExporter(path)
+	ex.Mode = 0600
+
+	if err := ex.Export("first"); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	// make mtime distinguishable
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("failed to change mtime: %s", err)
+	}
+
+	if err := ex.Export("first"); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat: %s", err)
+	}
+
+	if !info.ModTime().Equal(past) {
+		t.Errorf("unchanged file should not be rewritten: mtime=%s", info.ModTime())
+	}
+
+	if err := ex.Export("second"); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat: %s", err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected mode: %04o", info.Mode().Perm())
+	}
+
+	if info.ModTime().Equal(past) {
+		t.Errorf("changed file should be rewritten")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("temporary files should not be left: %v", entries)
+	}
+}
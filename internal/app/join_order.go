@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JoinOrder is an order of parameters whose values are joined into one environment variable.
+type JoinOrder string
+
+const (
+	// JoinOrderName sorts parameters by path.
+	JoinOrderName JoinOrder = "name"
+
+	// JoinOrderLastModified sorts parameters by last modified time, oldest first.
+	// Parameters modified at the same time are sorted by path.
+	JoinOrderLastModified JoinOrder = "lastmodified"
+)
+
+func ParseJoinOrder(s string) (JoinOrder, error) {
+	switch o := JoinOrder(s); o {
+	case JoinOrderName, JoinOrderLastModified:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unsupported join order: %s", s)
+	}
+}
+
+// Sort returns a copy of params sorted in the order.
+// Empty order is same as JoinOrderName.
+func (o JoinOrder) Sort(params []Parameter) []Parameter {
+	sorted := make([]Parameter, len(params))
+	copy(sorted, params)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if o == JoinOrderLastModified && !sorted[i].LastModified.Equal(sorted[j].LastModified) {
+			return sorted[i].LastModified.Before(sorted[j].LastModified)
+		}
+
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	return sorted
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/samber/lo"
+)
+
+func TestJoinOrderSort(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	params := []Parameter{
+		{Path: "/hosts/c", LastModified: base.Add(1 * time.Hour)},
+		{Path: "/hosts/a", LastModified: base.Add(2 * time.Hour)},
+		{Path: "/hosts/d", LastModified: base},
+		{Path: "/hosts/b", LastModified: base},
+	}
+
+	tests := []struct {
+		order JoinOrder
+		want  []string
+	}{
+		{order: "", want: []string{"/hosts/a", "/hosts/b", "/hosts/c", "/hosts/d"}},
+		{order: JoinOrderName, want: []string{"/hosts/a", "/hosts/b", "/hosts/c", "/hosts/d"}},
+		{order: JoinOrderLastModified, want: []string{"/hosts/b", "/hosts/d", "/hosts/c", "/hosts/a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			got := lo.Map(tt.order.Sort(params), func(p Parameter, _ int) string {
+				return p.Path
+			})
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Sort() has diff:\n%s", diff)
+			}
+		})
+	}
+
+	if params[0].Path != "/hosts/c" {
+		t.Errorf("Sort() should not modify params")
+	}
+}
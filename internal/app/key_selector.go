@@ -0,0 +1,169 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeySelector selects a field of a JSON value, like `.tls.cert`, `.hosts[0]` or `.["key.with.dot"]`.
+// `.` selects the whole value.
+type KeySelector struct {
+	raw   string
+	steps []keySelectorStep
+}
+
+type keySelectorStep struct {
+	key   string
+	index int
+
+	// isIndex is true if the step selects an element of array by index.
+	isIndex bool
+}
+
+func ParseKeySelector(s string) (*KeySelector, error) {
+	if !strings.HasPrefix(s, ".") {
+		return nil, fmt.Errorf("key selector must start with `.`: %s", s)
+	}
+
+	steps := []keySelectorStep{}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+
+			if i == len(s) {
+				if len(steps) == 0 {
+					// `.` selects the whole value
+					break
+				}
+
+				return nil, fmt.Errorf("key selector ends with `.`: %s", s)
+			}
+
+			if s[i] == '[' {
+				continue
+			}
+
+			end := strings.IndexAny(s[i:], ".[]")
+			if end == -1 {
+				end = len(s) - i
+			}
+
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in key selector: %s", s)
+			}
+
+			steps = append(steps, keySelectorStep{key: s[i : i+end]})
+			i += end
+		case '[':
+			step, n, err := parseKeySelectorBracket(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid key selector %s: %w", s, err)
+			}
+
+			steps = append(steps, step)
+			i += n
+		default:
+			return nil, fmt.Errorf("invalid key selector %s: unexpected %q at %d", s, s[i], i)
+		}
+	}
+
+	return &KeySelector{
+		raw:   s,
+		steps: steps,
+	}, nil
+}
+
+// parseKeySelectorBracket parses `[0]` or `["key"]` at the beginning of s,
+// and returns the step and length of parsed string.
+func parseKeySelectorBracket(s string) (keySelectorStep, int, error) {
+	if strings.HasPrefix(s, `["`) {
+		// find closing quote, skipping escaped characters
+		for i := 2; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return keySelectorStep{}, 0, fmt.Errorf("`]` is expected after quoted key")
+				}
+
+				key, err := strconv.Unquote(s[1 : i+1])
+				if err != nil {
+					return keySelectorStep{}, 0, fmt.Errorf("invalid quoted key %s", s[1:i+1])
+				}
+
+				return keySelectorStep{key: key}, i + 2, nil
+			}
+		}
+
+		return keySelectorStep{}, 0, fmt.Errorf("unterminated quoted key")
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end == -1 {
+		return keySelectorStep{}, 0, fmt.Errorf("unterminated `[`")
+	}
+
+	index, err := strconv.Atoi(s[1:end])
+	if err != nil || index < 0 {
+		return keySelectorStep{}, 0, fmt.Errorf("invalid index %s", s[1:end])
+	}
+
+	return keySelectorStep{index: index, isIndex: true}, end + 1, nil
+}
+
+func (s KeySelector) String() string {
+	return s.raw
+}
+
+// Select parses v as JSON, and returns the selected field.
+// A string field is returned as is, null as empty string, and others are encoded as JSON.
+// found is false if the field does not exist.
+func (s KeySelector) Select(v string) (selected string, found bool, err error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(v)))
+	dec.UseNumber()
+
+	var current any
+	if err := dec.Decode(&current); err != nil {
+		return "", false, fmt.Errorf("failed to parse value as JSON: %w", err)
+	}
+
+	for _, step := range s.steps {
+		var ok bool
+
+		if step.isIndex {
+			arr, isArray := current.([]any)
+			ok = isArray && step.index < len(arr)
+			if ok {
+				current = arr[step.index]
+			}
+		} else {
+			obj, isObject := current.(map[string]any)
+			current, ok = obj[step.key]
+			ok = isObject && ok
+		}
+
+		if !ok {
+			return "", false, nil
+		}
+	}
+
+	switch current := current.(type) {
+	case string:
+		return current, true, nil
+	case nil:
+		return "", true, nil
+	default:
+		b, err := json.Marshal(current)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to encode selected value: %w", err)
+		}
+
+		return string(b), true, nil
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeySelectorSelect(t *testing.T) {
+	value := `{
+  "password": "secret",
+  "port": 5432,
+  "tls": {"cert": "CERT", "ca": ["CA1", "CA2"]},
+  "hosts": [{"name": "a"}, {"name": "b"}],
+  "key.with.dot": "dot",
+  "none": null
+}`
+
+	tests := []struct {
+		selector string
+		want     string
+		found    bool
+	}{
+		{selector: ".password", want: "secret", found: true},
+		{selector: ".port", want: "5432", found: true},
+		{selector: ".tls.cert", want: "CERT", found: true},
+		{selector: ".tls.ca[1]", want: "CA2", found: true},
+		{selector: ".tls.ca", want: `["CA1","CA2"]`, found: true},
+		{selector: ".tls", want: `{"ca":["CA1","CA2"],"cert":"CERT"}`, found: true},
+		{selector: ".hosts[1].name", want: "b", found: true},
+		{selector: `.["key.with.dot"]`, want: "dot", found: true},
+		{selector: `.tls["cert"]`, want: "CERT", found: true},
+		{selector: ".none", want: "", found: true},
+		{selector: ".missing", found: false},
+		{selector: ".tls.missing", found: false},
+		{selector: ".tls.ca[2]", found: false},
+		{selector: ".password.sub", found: false},
+		{selector: ".hosts.name", found: false},
+		{selector: ".tls[0]", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseKeySelector(tt.selector)
+			if err != nil {
+				t.Fatalf("failed to parse: %s", err)
+			}
+
+			got, found, err := selector.Select(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if found != tt.found {
+				t.Errorf("unexpected found: %t", found)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Select() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeySelectorSelectWhole(t *testing.T) {
+	selector, err := ParseKeySelector(".")
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+
+	got, found, err := selector.Select(`{"a": 1}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !found || got != `{"a":1}` {
+		t.Errorf("unexpected result: %s, %t", got, found)
+	}
+
+	if _, _, err := selector.Select("not json"); err == nil {
+		t.Errorf("should be error for invalid JSON")
+	}
+}
+
+func TestParseKeySelectorReturnsError(t *testing.T) {
+	tests := []string{
+		"",
+		"password",
+		".a.",
+		".a..b",
+		".a[",
+		".a[x]",
+		".a[-1]",
+		`.["unterminated]`,
+		`.["a"x]`,
+		".a]",
+	}
+
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			if _, err := ParseKeySelector(s); err == nil {
+				t.Errorf("should be error")
+			}
+		})
+	}
+}
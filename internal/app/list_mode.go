@@ -0,0 +1,28 @@
+package app
+
+import (
+	"fmt"
+)
+
+// ListMode is a way to export items of StringList parameters.
+type ListMode string
+
+const (
+	// ListModeNone exports StringList values as is, joined by comma.
+	ListModeNone ListMode = ""
+
+	// ListModeIndex exports each item as its own environment variable, like NAME_0, NAME_1 and NAME_COUNT.
+	ListModeIndex ListMode = "index"
+
+	// ListModeJoin exports items joined by a separator.
+	ListModeJoin ListMode = "join"
+)
+
+func ParseListMode(s string) (ListMode, error) {
+	switch m := ListMode(s); m {
+	case ListModeIndex, ListModeJoin:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unsupported list mode: %s", s)
+	}
+}
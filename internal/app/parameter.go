@@ -1,6 +1,36 @@
 package app
 
+import (
+	"strings"
+	"time"
+)
+
+// ParameterType is a type of parameter in SSM Parameter Store.
+type ParameterType string
+
+const (
+	ParameterTypeString       ParameterType = "String"
+	ParameterTypeStringList   ParameterType = "StringList"
+	ParameterTypeSecureString ParameterType = "SecureString"
+)
+
 type Parameter struct {
 	Path  string
 	Value string
+
+	// Type is a type of the parameter. It may be empty if unknown.
+	Type ParameterType
+
+	// LastModified is the time when the parameter was modified last. It may be zero if unknown.
+	LastModified time.Time
+}
+
+// Items returns items of the parameter as a list.
+// Values of StringList are split by comma, and values of other types are a list of one item.
+func (p Parameter) Items() []string {
+	if p.Type == ParameterTypeStringList {
+		return strings.Split(p.Value, ",")
+	}
+
+	return []string{p.Value}
 }
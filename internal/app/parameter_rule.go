@@ -27,6 +27,10 @@ type ParameterRule struct {
 
 	// Level means how deep the path should be searched.
 	Level ParameterLevel
+
+	// Required is a flag to fail when no parameter is found at the path.
+	// By default, missing parameters are skipped.
+	Required bool
 }
 
 // NewParameterRule creates a new ParameterRule.
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -28,12 +29,6 @@ func NewParameterStore(client *ssm.Client, conn SSMConnector) *ParameterStore {
 func (c *ParameterStore) Store(ctx context.Context, rules []ParameterRule) error {
 	c.Parameters = []Parameter{}
 
-	paths := map[ParameterLevel][]string{
-		ParameterLevelStrict: {},
-		ParameterLevelUnder:  {},
-		ParameterLevelAll:    {},
-	}
-
 	// Rules that represent a broader range come first.
 	sort.Slice(rules, func(i, j int) bool {
 		if rules[i].Level == rules[j].Level {
@@ -55,49 +50,57 @@ func (c *ParameterStore) Store(ctx context.Context, rules []ParameterRule) error
 		}
 
 		switch rule.Level {
-		case ParameterLevelStrict:
-			paths[ParameterLevelStrict] = append(paths[ParameterLevelStrict], rule.Path)
-		case ParameterLevelUnder:
-			paths[ParameterLevelUnder] = append(paths[ParameterLevelUnder], rule.Path)
-		case ParameterLevelAll:
-			paths[ParameterLevelAll] = append(paths[ParameterLevelAll], rule.Path)
+		case ParameterLevelStrict, ParameterLevelUnder, ParameterLevelAll:
+			filteredRules = append(filteredRules, rule)
 		default:
 			slog.Warn("invalid ParameterRule path level", slog.Int("level", int(rule.Level)))
 		}
-
-		filteredRules = append(filteredRules, rule)
 	}
 
-	add := func(params map[string]string) {
-		for key, value := range params {
-			c.Parameters = append(c.Parameters, Parameter{
-				Path:  key,
-				Value: value,
-			})
+	add := func(params map[string]Parameter) {
+		for _, param := range params {
+			c.Parameters = append(c.Parameters, param)
 		}
 	}
 
-	if p, err := c.conn.fetchParametersByNames(ctx, c.client, paths[ParameterLevelStrict]); err != nil {
-		return fmt.Errorf("failed to fetch parameters from SSM by strict paths %v: %w", paths[ParameterLevelStrict], err)
-	} else {
-		add(p)
-	}
+	strictRules, pathRules := lo.FilterReject(filteredRules, func(r ParameterRule, _ int) bool {
+		return r.Level == ParameterLevelStrict
+	})
+
+	names := lo.Map(strictRules, func(r ParameterRule, _ int) string {
+		return r.Path
+	})
 
-	if p, err := c.conn.fetchParametersByPaths(ctx, c.client, paths[ParameterLevelUnder], false); err != nil {
-		return fmt.Errorf("failed to fetch parameters from SSM by just under paths %v: %w", paths[ParameterLevelUnder], err)
+	if p, err := c.conn.fetchParametersByNames(ctx, c.client, names); err != nil {
+		return c.fetchError(err, strictRules...)
 	} else {
 		add(p)
 	}
 
-	if p, err := c.conn.fetchParametersByPaths(ctx, c.client, paths[ParameterLevelAll], true); err != nil {
-		return fmt.Errorf("failed to fetch parameters from SSM by under paths recursively %v: %w", paths[ParameterLevelAll], err)
-	} else {
-		add(p)
+	// Fetch parameters rule by rule to tell which rule failed.
+	for _, rule := range pathRules {
+		if p, err := c.conn.fetchParametersByPaths(ctx, c.client, []string{rule.Path}, rule.Level == ParameterLevelAll); err != nil {
+			return c.fetchError(err, rule)
+		} else {
+			add(p)
+		}
 	}
 
 	return nil
 }
 
+func (c ParameterStore) fetchError(err error, rules ...ParameterRule) error {
+	paths := lo.Map(rules, func(r ParameterRule, _ int) string {
+		return r.String()
+	})
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out to fetch parameters from SSM for %v: %w", paths, err)
+	}
+
+	return fmt.Errorf("failed to fetch parameters from SSM for %v: %w", paths, err)
+}
+
 func (c ParameterStore) Retrieve(path string, level ParameterLevel) ([]Parameter, error) {
 	switch level {
 	case ParameterLevelStrict:
@@ -145,3 +148,35 @@ func (c ParameterStore) SearchByPath(path string, recursive bool) []Parameter {
 		return true
 	})
 }
+
+// Diff returns paths of parameters that are added, removed or modified in other.
+// Returned paths are sorted.
+func (c ParameterStore) Diff(other ParameterStore) []string {
+	current := make(map[string]string, len(c.Parameters))
+	for _, p := range c.Parameters {
+		current[p.Path] = p.Value
+	}
+
+	next := make(map[string]string, len(other.Parameters))
+	for _, p := range other.Parameters {
+		next[p.Path] = p.Value
+	}
+
+	changed := []string{}
+
+	for path, value := range current {
+		if v, ok := next[path]; !ok || v != value {
+			changed = append(changed, path)
+		}
+	}
+
+	for path := range next {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
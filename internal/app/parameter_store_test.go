@@ -2,9 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -28,6 +31,7 @@ func TestParameterStoreStore(t *testing.T) {
 		{
 			Path:  "/foo/v1",
 			Value: "this is /foo/v1",
+			Type:  ParameterTypeStringList,
 		},
 		{
 			Path:  "/bar/v1",
@@ -57,6 +61,9 @@ func TestParameterStoreStore(t *testing.T) {
 			"/buzz/a/v2":   "this is /buzz/a/v2",
 			"/buzz/a/b/v3": "this is /buzz/a/b/v3",
 		},
+		types: map[string]ParameterType{
+			"/foo/v1": ParameterTypeStringList,
+		},
 	}
 
 	ctx := context.Background()
@@ -149,3 +156,88 @@ func TestParameterStoreRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestParameterStoreDiff(t *testing.T) {
+	current := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/foo/v1", Value: "v1"},
+			{Path: "/foo/v2", Value: "v2"},
+			{Path: "/foo/v3", Value: "v3"},
+		},
+	}
+
+	tests := []struct {
+		title string
+		next  []Parameter
+		want  []string
+	}{
+		{
+			title: "no changes",
+			next: []Parameter{
+				{Path: "/foo/v3", Value: "v3"},
+				{Path: "/foo/v2", Value: "v2"},
+				{Path: "/foo/v1", Value: "v1"},
+			},
+			want: []string{},
+		},
+		{
+			title: "modified, removed and added",
+			next: []Parameter{
+				{Path: "/foo/v1", Value: "v1"},
+				{Path: "/foo/v2", Value: "v2 modified"},
+				{Path: "/foo/v4", Value: "v4"},
+			},
+			want: []string{"/foo/v2", "/foo/v3", "/foo/v4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := current.Diff(ParameterStore{Parameters: tt.next})
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Diff() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+type ErrorSSMConnector struct {
+	err error
+}
+
+func (c ErrorSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]Parameter, error) {
+	return nil, c.err
+}
+
+func (c ErrorSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]Parameter, error) {
+	if len(names) == 0 {
+		return map[string]Parameter{}, nil
+	}
+
+	return nil, c.err
+}
+
+func TestParameterStoreStoreTimeout(t *testing.T) {
+	rules := []ParameterRule{
+		{
+			Path:  "/foo/",
+			Level: ParameterLevelAll,
+		},
+	}
+
+	store := NewParameterStore(nil, ErrorSSMConnector{err: context.DeadlineExceeded})
+
+	err := store.Store(context.Background(), rules)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error should wrap context.DeadlineExceeded: %s", err)
+	}
+
+	if !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "/foo/**/*") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
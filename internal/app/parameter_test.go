@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParameterItems(t *testing.T) {
+	tests := []struct {
+		title string
+		param Parameter
+		want  []string
+	}{
+		{
+			title: "StringList",
+			param: Parameter{Path: "/hosts", Value: "a.local,b.local,c.local", Type: ParameterTypeStringList},
+			want:  []string{"a.local", "b.local", "c.local"},
+		},
+		{
+			title: "String",
+			param: Parameter{Path: "/hosts", Value: "a.local,b.local", Type: ParameterTypeString},
+			want:  []string{"a.local,b.local"},
+		},
+		{
+			title: "unknown type",
+			param: Parameter{Path: "/hosts", Value: "a.local,b.local"},
+			want:  []string{"a.local,b.local"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.param.Items()); diff != "" {
+				t.Errorf("Items() has diff:\n%s", diff)
+			}
+		})
+	}
+}
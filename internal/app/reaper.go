@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reaper waits for all child processes, including orphaned processes
+// adopted by ssmwrap running as PID 1, so that they don't remain as zombies.
+// Exit statuses of processes spawned by Spawn are delivered to their waiters.
+type Reaper struct {
+	mu      sync.Mutex
+	waiters map[int]chan syscall.WaitStatus
+
+	// holds is the number of Hold not released yet.
+	// While holds is positive, only processes spawned by Spawn are reaped.
+	holds int
+}
+
+func NewReaper() *Reaper {
+	return &Reaper{
+		waiters: map[int]chan syscall.WaitStatus{},
+	}
+}
+
+// Run reaps child processes whenever SIGCHLD is received until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGCHLD)
+	defer signal.Stop(sigCh)
+
+	// reap processes exited before starting to watch SIGCHLD
+	r.reap()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			r.reap()
+		}
+	}
+}
+
+// Spawn calls start which starts a process and returns its pid,
+// and returns a channel that receives exit status of the process.
+// The process is never reaped before it is registered as a waiter.
+func (r *Reaper) Spawn(start func() (int, error)) (<-chan syscall.WaitStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pid, err := start()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan syscall.WaitStatus, 1)
+	r.waiters[pid] = ch
+
+	return ch, nil
+}
+
+// Hold stops reaping processes other than ones spawned by Spawn until the returned function is called.
+// It must be held while other code in ssmwrap may start child processes and wait for them by itself,
+// e.g. credential_process run by the AWS SDK while fetching parameters.
+// Otherwise, such processes are reaped by Reaper and waiting for them fails with ECHILD.
+// Hold of nil Reaper does nothing.
+func (r *Reaper) Hold() (release func()) {
+	if r == nil {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	r.holds++
+	r.mu.Unlock()
+
+	return sync.OnceFunc(func() {
+		r.mu.Lock()
+		r.holds--
+		r.mu.Unlock()
+
+		// reap processes exited while holding
+		r.reap()
+	})
+}
+
+func (r *Reaper) reap() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if 0 < r.holds {
+		r.reapWaiters()
+		return
+	}
+
+	for {
+		var ws syscall.WaitStatus
+
+		pid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if errors.Is(err, syscall.EINTR) {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return
+		}
+
+		if ch, ok := r.waiters[pid]; ok {
+			ch <- ws
+			delete(r.waiters, pid)
+			continue
+		}
+
+		slog.Debug("reaped orphaned process", slog.Int("pid", pid))
+	}
+}
+
+// reapWaiters reaps only processes spawned by Spawn.
+func (r *Reaper) reapWaiters() {
+	for pid, ch := range r.waiters {
+		var ws syscall.WaitStatus
+
+		wpid, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+		for errors.Is(err, syscall.EINTR) {
+			wpid, err = syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+		}
+		if err != nil || wpid <= 0 {
+			continue
+		}
+
+		ch <- ws
+		delete(r.waiters, pid)
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReaperSpawn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	reaper := NewReaper()
+
+	// stop reaping before other tests start their children
+	stopped := make(chan struct{})
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	go func() {
+		defer close(stopped)
+		reaper.Run(ctx)
+	}()
+
+	sv := NewSupervisor([]string{"sh", "-c", "exit 3"}, nil)
+	sv.Reaper = reaper
+
+	if err := sv.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	var exitErr *ExitError
+	if err := sv.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exitErr.Code != 3 {
+		t.Errorf("unexpected exit code: %d", exitErr.Code)
+	}
+}
+
+func TestReaperReapsUnknownProcess(t *testing.T) {
+	// Started but never waited, like an orphaned process adopted by PID 1.
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	pid := cmd.Process.Pid
+
+	// wait for the process to exit and become a zombie
+	time.Sleep(300 * time.Millisecond)
+
+	NewReaper().reap()
+
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); !errors.Is(err, syscall.ECHILD) {
+		t.Errorf("process %d is not reaped: %v", pid, err)
+	}
+}
+
+func TestReaperHold(t *testing.T) {
+	reaper := NewReaper()
+	release := reaper.Hold()
+
+	// started and waited by others, like credential_process run by the AWS SDK
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	sv := NewSupervisor([]string{"sh", "-c", "exit 3"}, nil)
+	sv.Reaper = reaper
+
+	if err := sv.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	// wait for the processes to exit and become zombies
+	time.Sleep(300 * time.Millisecond)
+
+	reaper.reap()
+
+	// processes spawned by Spawn are reaped even while holding
+	var exitErr *ExitError
+	if err := sv.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("process should be waited by its owner: %v", err)
+	}
+
+	release()
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartPolicy determines whether the command is restarted when it exits in supervisor mode.
+type RestartPolicy string
+
+const (
+	// RestartPolicyNever never restarts the command.
+	RestartPolicyNever RestartPolicy = "never"
+
+	// RestartPolicyOnFailure restarts the command only if it exits with non-zero status.
+	RestartPolicyOnFailure RestartPolicy = "on-failure"
+
+	// RestartPolicyAlways restarts the command whenever it exits.
+	RestartPolicyAlways RestartPolicy = "always"
+)
+
+// maxRestartBackoff is upper limit of delay before restarting the command.
+const maxRestartBackoff = 5 * time.Minute
+
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	switch p := RestartPolicy(s); p {
+	case RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid restart policy: %s", s)
+	}
+}
+
+// ShouldRestart reports whether the command exited with err should be restarted.
+func (p RestartPolicy) ShouldRestart(err error) bool {
+	switch p {
+	case RestartPolicyAlways:
+		return true
+	case RestartPolicyOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// restartBackoff returns delay before the n-th (0-origin) restart.
+// The delay is doubled each time from base, up to maxRestartBackoff.
+func restartBackoff(base time.Duration, n int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 0; i < n && delay < maxRestartBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRestartBackoff)
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+	failure := &ExitError{Code: 1}
+
+	tests := []struct {
+		policy RestartPolicy
+		err    error
+		want   bool
+	}{
+		{policy: RestartPolicyNever, err: nil, want: false},
+		{policy: RestartPolicyNever, err: failure, want: false},
+		{policy: RestartPolicyOnFailure, err: nil, want: false},
+		{policy: RestartPolicyOnFailure, err: failure, want: true},
+		{policy: RestartPolicyAlways, err: nil, want: true},
+		{policy: RestartPolicyAlways, err: failure, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			if got := tt.policy.ShouldRestart(tt.err); got != tt.want {
+				t.Errorf("ShouldRestart(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, s := range []string{"never", "on-failure", "always"} {
+		if p, err := ParseRestartPolicy(s); err != nil || string(p) != s {
+			t.Errorf("unexpected result for %s: %s, %v", s, p, err)
+		}
+	}
+
+	if _, err := ParseRestartPolicy("sometimes"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{n: 0, want: 1 * time.Second},
+		{n: 1, want: 2 * time.Second},
+		{n: 3, want: 8 * time.Second},
+		{n: 100, want: maxRestartBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := restartBackoff(time.Second, tt.n); got != tt.want {
+			t.Errorf("restartBackoff(1s, %d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+
+	if got := restartBackoff(0, 3); got != 0 {
+		t.Errorf("restartBackoff(0, 3) = %s, want 0", got)
+	}
+}
@@ -3,7 +3,12 @@ package app
 import (
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/samber/lo"
 )
 
 type Rule struct {
@@ -26,17 +31,66 @@ func (r Rule) String() string {
 		ss = append(ss, r.DestinationRule.TypeEnvOptions.String())
 	case DestinationTypeFile:
 		ss = append(ss, r.DestinationRule.TypeFileOptions.String())
+	case DestinationTypeBundle:
+		ss = append(ss, r.DestinationRule.TypeBundleOptions.String())
+	case DestinationTypeTemplate:
+		ss = append(ss, r.DestinationRule.TypeTemplateOptions.String())
+	case DestinationTypeDir:
+		ss = append(ss, r.DestinationRule.TypeDirOptions.String())
+	}
+
+	if r.DestinationRule.Decode != DecodingNone {
+		ss = append(ss, "decode="+string(r.DestinationRule.Decode))
+	}
+
+	if r.DestinationRule.Key != "" {
+		ss = append(ss, "key="+r.DestinationRule.Key)
+	}
+
+	if r.DestinationRule.KeyOptional {
+		ss = append(ss, "keyoptional=true")
+	}
+
+	if r.DestinationRule.List != ListModeNone {
+		ss = append(ss, "list="+string(r.DestinationRule.List))
+	}
+
+	if r.DestinationRule.ListSeparator != "" {
+		ss = append(ss, "listsep="+FormatSeparator(r.DestinationRule.ListSeparator))
+	}
+
+	if r.ParameterRule.Required {
+		ss = append(ss, "required=true")
 	}
 
 	return strings.Join(ss, ",")
 }
 
-func (r Rule) Execute(store ParameterStore) error {
+// Execute exports parameters in store according to the rule.
+// Destinations of exported parameters are recorded to exported.
+func (r Rule) Execute(store ParameterStore, exported *Exported) error {
 	params, err := store.Retrieve(r.ParameterRule.Path, r.ParameterRule.Level)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve parameters: %w", err)
 	}
 
+	if r.ParameterRule.Required && len(params) == 0 {
+		return &ParameterNotFoundError{Path: r.ParameterRule.Path}
+	}
+
+	switch r.DestinationRule.Type {
+	case DestinationTypeEnv:
+		if opts := r.DestinationRule.TypeEnvOptions; opts != nil && opts.Join {
+			return r.executeJoin(params, exported)
+		}
+	case DestinationTypeBundle:
+		return r.executeBundle(params, exported)
+	case DestinationTypeTemplate:
+		return r.executeTemplate(params, exported)
+	case DestinationTypeDir:
+		return r.executeDir(params, exported)
+	}
+
 	for _, p := range params {
 		var ex Exporter
 
@@ -48,27 +102,33 @@ func (r Rule) Execute(store ParameterStore) error {
 
 			envName := r.buildEnvName(p.Path)
 
-			ex = NewEnvExporter(envName)
-		case DestinationTypeFile:
-			if r.DestinationRule.TypeFileOptions == nil {
-				return fmt.Errorf("TypeFileOption is required for DestinationTypeFile")
+			if r.DestinationRule.TypeEnvOptions.Expand != ExpansionNone {
+				if err := r.exportExpanded(envName, p, exported); err != nil {
+					return err
+				}
+
+				continue
 			}
 
-			e := NewFileExporter(r.DestinationRule.To)
+			if r.DestinationRule.List == ListModeIndex {
+				if err := r.exportIndexed(envName, p, exported); err != nil {
+					return err
+				}
 
-			if r.DestinationRule.TypeFileOptions.Mode != 0 {
-				e.Mode = r.DestinationRule.TypeFileOptions.Mode
+				continue
 			}
 
-			if r.DestinationRule.TypeFileOptions.Uid != 0 {
-				e.Uid = r.DestinationRule.TypeFileOptions.Uid
+			ex = NewEnvExporter(envName, exported.Env)
+		case DestinationTypeFile:
+			if r.DestinationRule.TypeFileOptions == nil {
+				return fmt.Errorf("TypeFileOption is required for DestinationTypeFile")
 			}
 
-			if r.DestinationRule.TypeFileOptions.Gid != 0 {
-				e.Gid = r.DestinationRule.TypeFileOptions.Gid
+			if err := r.mkdirParent(*r.DestinationRule.TypeFileOptions, exported); err != nil {
+				return err
 			}
 
-			ex = e
+			ex = r.fileExporter(r.DestinationRule.To, *r.DestinationRule.TypeFileOptions)
 		default:
 			return fmt.Errorf("invalid destination type: %s", r.DestinationRule.Type)
 		}
@@ -79,18 +139,406 @@ func (r Rule) Execute(store ParameterStore) error {
 			slog.String("address", ex.Address()),
 		)
 
-		if err := ex.Export(p.Value); err != nil {
-			return fmt.Errorf("failed to export parameter for %s: %w", r.DestinationRule.To, err)
+		value, err := r.value(p)
+		if err != nil {
+			return &DestinationError{Address: ex.Address(), Err: err}
+		}
+
+		if err := ex.Export(value); err != nil {
+			return &DestinationError{Address: ex.Address(), Err: err}
+		}
+
+		if r.DestinationRule.Type == DestinationTypeFile {
+			exported.Files = append(exported.Files, ex.Address())
+		}
+	}
+
+	return nil
+}
+
+// executeJoin joins values of all params into one environment variable named by To.
+func (r Rule) executeJoin(params []Parameter, exported *Exported) error {
+	opts := r.DestinationRule.TypeEnvOptions
+
+	sep := opts.JoinSeparator
+	if sep == "" {
+		sep = defaultSeparator
+	}
+
+	ex := NewEnvExporter(r.DestinationRule.To, exported.Env)
+
+	values := make([]string, 0, len(params))
+	for _, p := range opts.JoinOrder.Sort(params) {
+		value, err := r.value(p)
+		if err != nil {
+			return &DestinationError{Address: ex.Address(), Err: err}
+		}
+
+		values = append(values, value)
+	}
+
+	slog.Debug(
+		"exporting parameters",
+		slog.String("type", string(r.DestinationRule.Type)),
+		slog.String("address", ex.Address()),
+		slog.Int("count", len(params)),
+	)
+
+	if err := ex.Export(strings.Join(values, sep)); err != nil {
+		return &DestinationError{Address: ex.Address(), Err: err}
+	}
+
+	return nil
+}
+
+// executeBundle writes all params into one file.
+func (r Rule) executeBundle(params []Parameter, exported *Exported) error {
+	opts := r.DestinationRule.TypeBundleOptions
+	if opts == nil {
+		return fmt.Errorf("TypeBundleOptions is required for DestinationTypeBundle")
+	}
+
+	bundle := NewBundle()
+
+	for _, p := range params {
+		key := []string{opts.envName(p.Path)}
+		if opts.Format.Structured() {
+			key = r.bundleKey(p.Path)
+		}
+
+		if err := bundle.Add(key, p.Value); err != nil {
+			return &DestinationError{Address: r.DestinationRule.To, Err: err}
+		}
+	}
+
+	content, err := bundle.Encode(opts.Format)
+	if err != nil {
+		return &DestinationError{Address: r.DestinationRule.To, Err: err}
+	}
+
+	return r.exportFile(content, opts.DestinationTypeFileOptions, len(params), exported)
+}
+
+// executeTemplate renders the template with params, and writes it into a file.
+func (r Rule) executeTemplate(params []Parameter, exported *Exported) error {
+	opts := r.DestinationRule.TypeTemplateOptions
+	if opts == nil {
+		return fmt.Errorf("TypeTemplateOptions is required for DestinationTypeTemplate")
+	}
+
+	content, err := NewTemplateRenderer(params).RenderFile(opts.Src)
+	if err != nil {
+		return &DestinationError{Address: r.DestinationRule.To, Err: err}
+	}
+
+	return r.exportFile(content, opts.DestinationTypeFileOptions, len(params), exported)
+}
+
+// executeDir writes each parameter into its own file under the destination directory.
+// The directory is updated atomically by AtomicDirWriter.
+func (r Rule) executeDir(params []Parameter, exported *Exported) error {
+	opts := r.DestinationRule.TypeDirOptions
+	if opts == nil {
+		return fmt.Errorf("TypeDirOptions is required for DestinationTypeDir")
+	}
+
+	files := make(map[string]string, len(params))
+	for _, p := range params {
+		rel := r.relativePath(p.Path)
+
+		value, err := r.value(p)
+		if err != nil {
+			return &DestinationError{Address: filepath.Join(r.DestinationRule.To, rel), Err: err}
+		}
+
+		files[rel] = value
+	}
+
+	w := AtomicDirWriter{
+		Dir:         r.DestinationRule.To,
+		FileOptions: opts.DestinationTypeFileOptions,
+		DirMode:     opts.DirMode,
+		DirUid:      opts.DirUid,
+		DirGid:      opts.DirGid,
+	}
+
+	slog.Debug(
+		"exporting parameters",
+		slog.String("type", string(r.DestinationRule.Type)),
+		slog.String("address", w.Dir),
+		slog.Int("count", len(params)),
+	)
+
+	created, err := w.Write(files)
+	exported.Created = append(exported.Created, created...)
+	if err != nil {
+		return &DestinationError{Address: w.Dir, Err: err}
+	}
+
+	rels := lo.Keys(files)
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		exported.Files = append(exported.Files, filepath.Join(w.Dir, rel))
+	}
+
+	return nil
+}
+
+// value returns the value of p to be exported.
+// The value is decoded by Decode, and then the field selected by Key is extracted.
+// If List is ListModeJoin, items of the value are joined instead.
+func (r Rule) value(p Parameter) (string, error) {
+	if r.DestinationRule.List == ListModeJoin {
+		sep := r.DestinationRule.ListSeparator
+		if sep == "" {
+			sep = defaultSeparator
+		}
+
+		return strings.Join(p.Items(), sep), nil
+	}
+
+	value, err := r.DestinationRule.Decode.Decode(p.Value)
+	if err != nil {
+		return "", fmt.Errorf("parameter %s: %w", p.Path, err)
+	}
+
+	if r.DestinationRule.Key == "" {
+		return value, nil
+	}
+
+	selector, err := ParseKeySelector(r.DestinationRule.Key)
+	if err != nil {
+		return "", err
+	}
+
+	selected, found, err := selector.Select(value)
+	if err != nil {
+		return "", fmt.Errorf("parameter %s: %w", p.Path, err)
+	}
+
+	if !found {
+		if r.DestinationRule.KeyOptional {
+			slog.Debug("key not found, exporting empty value", slog.String("path", p.Path), slog.String("key", selector.String()))
+			return "", nil
+		}
+
+		return "", fmt.Errorf("parameter %s: key %s not found", p.Path, selector)
+	}
+
+	return selected, nil
+}
+
+// exportIndexed exports each item of the value of p as its own environment variable, like NAME_0 and NAME_1.
+// The number of items is exported as NAME_COUNT.
+func (r Rule) exportIndexed(envName string, p Parameter, exported *Exported) error {
+	items := p.Items()
+
+	names := make([]string, 0, len(items)+1)
+	values := make([]string, 0, len(items)+1)
+
+	for i, item := range items {
+		names = append(names, fmt.Sprintf("%s_%d", envName, i))
+		values = append(values, item)
+	}
+
+	names = append(names, envName+"_COUNT")
+	values = append(values, strconv.Itoa(len(items)))
+
+	for i, name := range names {
+		slog.Debug(
+			"exporting parameter",
+			slog.String("type", string(r.DestinationRule.Type)),
+			slog.String("address", name),
+		)
+
+		ex := NewEnvExporter(name, exported.Env)
+		if err := ex.Export(values[i]); err != nil {
+			return &DestinationError{Address: ex.Address(), Err: err}
 		}
 	}
 
 	return nil
 }
 
+// exportExpanded expands the value of p into many environment variables named with envName and keys in the value.
+func (r Rule) exportExpanded(envName string, p Parameter, exported *Exported) error {
+	opts := r.DestinationRule.TypeEnvOptions
+
+	sep := opts.ExpandSeparator
+	if sep == "" {
+		sep = defaultExpandSeparator
+	}
+
+	value, err := r.value(p)
+	if err != nil {
+		return &DestinationError{Address: envName, Err: err}
+	}
+
+	expanded, err := opts.Expand.Expand(value, sep)
+	if err != nil {
+		return &DestinationError{Address: envName, Err: fmt.Errorf("parameter %s: %w", p.Path, err)}
+	}
+
+	names := map[string]string{}
+	for key, v := range expanded {
+		name := strings.ToUpper(envName + sep + key)
+		if other, ok := names[name]; ok {
+			return &DestinationError{Address: name, Err: fmt.Errorf("parameter %s: keys %s and %s conflict", p.Path, other, key)}
+		}
+
+		names[name] = key
+
+		slog.Debug(
+			"exporting parameter",
+			slog.String("type", string(r.DestinationRule.Type)),
+			slog.String("address", name),
+		)
+
+		ex := NewEnvExporter(name, exported.Env)
+		if err := ex.Export(v); err != nil {
+			return &DestinationError{Address: ex.Address(), Err: err}
+		}
+	}
+
+	return nil
+}
+
+// relativePath returns sub-path of the parameter at path under the rule path.
+// If the rule is strict, it returns the last element of path.
+func (r Rule) relativePath(path string) string {
+	if r.ParameterRule.Level == ParameterLevelStrict {
+		parts := strings.Split(path, "/")
+		return parts[len(parts)-1]
+	}
+
+	return strings.TrimPrefix(path, r.ParameterRule.Path)
+}
+
+// bundleKey returns key of the parameter at path in structured bundle.
+// Nested paths are split into nested keys, named as same as environment variables.
+func (r Rule) bundleKey(path string) []string {
+	opts := r.DestinationRule.TypeBundleOptions
+
+	rel := r.relativePath(path)
+	if opts.EntirePath {
+		rel = strings.TrimPrefix(path, "/")
+	}
+
+	key := strings.Split(rel, "/")
+	key[0] = opts.Prefix + key[0]
+
+	for i := range key {
+		key[i] = strings.ToUpper(key[i])
+	}
+
+	return key
+}
+
+// exportFile writes content built from count parameters into the destination file.
+func (r Rule) exportFile(content string, opts DestinationTypeFileOptions, count int, exported *Exported) error {
+	if err := r.mkdirParent(opts, exported); err != nil {
+		return err
+	}
+
+	ex := r.fileExporter(r.DestinationRule.To, opts)
+
+	slog.Debug(
+		"exporting parameters",
+		slog.String("type", string(r.DestinationRule.Type)),
+		slog.String("address", ex.Address()),
+		slog.Int("count", count),
+	)
+
+	if err := ex.Export(content); err != nil {
+		return &DestinationError{Address: ex.Address(), Err: err}
+	}
+
+	exported.Files = append(exported.Files, ex.Address())
+
+	return nil
+}
+
+// mkdirParent creates missing parent directories of the destination file if opts.Mkdir is true.
+// Created directories are recorded to exported.Created.
+func (r Rule) mkdirParent(opts DestinationTypeFileOptions, exported *Exported) error {
+	if !opts.Mkdir {
+		return nil
+	}
+
+	mode := opts.DirMode
+	if mode == 0 {
+		mode = 0755
+	}
+
+	created, err := mkdirAll(filepath.Dir(r.DestinationRule.To), mode, opts.DirUid, opts.DirGid)
+	exported.Created = append(exported.Created, created...)
+
+	for _, dir := range created {
+		slog.Info("created directory", slog.String("path", dir))
+	}
+
+	if err != nil {
+		return &DestinationError{Address: r.DestinationRule.To, Err: err}
+	}
+
+	return nil
+}
+
+func (r Rule) fileExporter(path string, opts DestinationTypeFileOptions) *FileExporter {
+	e := NewFileExporter(path)
+
+	if opts.Mode != 0 {
+		e.Mode = opts.Mode
+	}
+
+	if opts.Uid != 0 {
+		e.Uid = opts.Uid
+	}
+
+	if opts.Gid != 0 {
+		e.Gid = opts.Gid
+	}
+
+	e.FollowSymlink = opts.FollowSymlink
+
+	return e
+}
+
+// buildEnvName builds name of environment variable for the parameter at path.
+// If To is set, it is used as the name, and its placeholders are replaced. See IsEnvNameTemplate.
 func (r Rule) buildEnvName(path string) string {
+	if r.DestinationRule.To == "" {
+		return r.DestinationRule.TypeEnvOptions.envName(path)
+	}
+
+	rel := r.relativePath(path)
+	parts := strings.Split(path, "/")
+
+	return strings.NewReplacer(
+		envNamePlaceholderPath, strings.ToUpper(strings.ReplaceAll(rel, "/", "_")),
+		envNamePlaceholderName, strings.ToUpper(parts[len(parts)-1]),
+	).Replace(r.DestinationRule.To)
+}
+
+const (
+	// envNamePlaceholderPath is replaced with path of parameter relative to the rule path, like DB_PASSWORD for /prod/db/password under /prod/.
+	envNamePlaceholderPath = "{path}"
+
+	// envNamePlaceholderName is replaced with the last element of path of parameter, like PASSWORD for /prod/db/password.
+	envNamePlaceholderName = "{name}"
+)
+
+// IsEnvNameTemplate reports whether name contains placeholders replaced by names of parameters.
+func IsEnvNameTemplate(name string) bool {
+	return strings.Contains(name, envNamePlaceholderPath) || strings.Contains(name, envNamePlaceholderName)
+}
+
+// envName builds name of environment variable for the parameter at path.
+func (o DestinationTypeEnvOptions) envName(path string) string {
 	var envName string
 
-	if r.DestinationRule.TypeEnvOptions.EntirePath {
+	if o.EntirePath {
 		envName += strings.ReplaceAll(path, "/", "_")
 		envName = strings.TrimPrefix(envName, "_")
 	} else {
@@ -98,8 +546,8 @@ func (r Rule) buildEnvName(path string) string {
 		envName += parts[len(parts)-1]
 	}
 
-	if r.DestinationRule.TypeEnvOptions.Prefix != "" {
-		envName = r.DestinationRule.TypeEnvOptions.Prefix + envName
+	if o.Prefix != "" {
+		envName = o.Prefix + envName
 	}
 
 	return strings.ToUpper(envName)
@@ -1,6 +1,14 @@
 package app
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestRuleString(t *testing.T) {
 	tests := []struct {
@@ -45,6 +53,95 @@ func TestRuleString(t *testing.T) {
 			},
 			want: "path=/path/to/param,type=file,to=/path/to/file,mode=0644,uid=1000,gid=2000",
 		},
+		{
+			title: "type file with decode",
+			rule: Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/path/to/param",
+					Level: ParameterLevelStrict,
+				},
+				DestinationRule: DestinationRule{
+					Type:            DestinationTypeFile,
+					To:              "/path/to/file",
+					Decode:          DecodingBase64,
+					TypeFileOptions: &DestinationTypeFileOptions{},
+				},
+			},
+			want: "path=/path/to/param,type=file,to=/path/to/file,mode=0000,uid=0,gid=0,decode=base64",
+		},
+		{
+			title: "type env with list",
+			rule: Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/path/to/param",
+					Level: ParameterLevelStrict,
+				},
+				DestinationRule: DestinationRule{
+					Type:           DestinationTypeEnv,
+					List:           ListModeJoin,
+					ListSeparator:  ", ",
+					TypeEnvOptions: &DestinationTypeEnvOptions{},
+				},
+			},
+			want: `path=/path/to/param,type=env,prefix=,entirepath=false,list=join,listsep=\x2c `,
+		},
+		{
+			title: "type file with mkdir",
+			rule: Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/path/to/param",
+					Level: ParameterLevelStrict,
+				},
+				DestinationRule: DestinationRule{
+					Type: DestinationTypeFile,
+					To:   "/path/to/file",
+					TypeFileOptions: &DestinationTypeFileOptions{
+						Mkdir:   true,
+						DirMode: 0700,
+						DirUid:  1000,
+					},
+				},
+			},
+			want: "path=/path/to/param,type=file,to=/path/to/file,mode=0000,uid=0,gid=0,mkdir=true,dirmode=0700,diruid=1000",
+		},
+		{
+			title: "type bundle",
+			rule: Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/path/to/",
+					Level: ParameterLevelUnder,
+				},
+				DestinationRule: DestinationRule{
+					Type: DestinationTypeBundle,
+					To:   "/path/to/file",
+					TypeBundleOptions: &DestinationTypeBundleOptions{
+						Format: BundleFormatJSON,
+						DestinationTypeEnvOptions: DestinationTypeEnvOptions{
+							Prefix: "TEST_",
+						},
+						DestinationTypeFileOptions: DestinationTypeFileOptions{
+							Mode: 0600,
+						},
+					},
+				},
+			},
+			want: "path=/path/to/*,type=bundle,to=/path/to/file,format=json,prefix=TEST_,mode=0600,uid=0,gid=0",
+		},
+		{
+			title: "required",
+			rule: Rule{
+				ParameterRule: ParameterRule{
+					Path:     "/path/to/param",
+					Level:    ParameterLevelStrict,
+					Required: true,
+				},
+				DestinationRule: DestinationRule{
+					Type:           DestinationTypeEnv,
+					TypeEnvOptions: &DestinationTypeEnvOptions{},
+				},
+			},
+			want: "path=/path/to/param,type=env,prefix=,entirepath=false,required=true",
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,9 +157,12 @@ func TestRuleString(t *testing.T) {
 func TestRuleBuildEnvName(t *testing.T) {
 	tests := []struct {
 		title      string
+		rulePath   string
+		level      ParameterLevel
 		path       string
 		prefix     string
 		entirePath bool
+		to         string
 		want       string
 	}{
 		{
@@ -86,12 +186,49 @@ func TestRuleBuildEnvName(t *testing.T) {
 			entirePath: true,
 			want:       "PATH_TO_PARAM",
 		},
+		{
+			title:    "to for strict path",
+			rulePath: "/prod/db/pass",
+			level:    ParameterLevelStrict,
+			path:     "/prod/db/pass",
+			to:       "DATABASE_PASSWORD",
+			want:     "DATABASE_PASSWORD",
+		},
+		{
+			title:    "to with name",
+			rulePath: "/prod/",
+			level:    ParameterLevelAll,
+			path:     "/prod/db/pass",
+			to:       "APP_{name}",
+			want:     "APP_PASS",
+		},
+		{
+			title:    "to with path",
+			rulePath: "/prod/",
+			level:    ParameterLevelAll,
+			path:     "/prod/db/pass",
+			to:       "APP_{path}_V1",
+			want:     "APP_DB_PASS_V1",
+		},
+		{
+			title:    "to with path for strict path",
+			rulePath: "/prod/db/pass",
+			level:    ParameterLevelStrict,
+			path:     "/prod/db/pass",
+			to:       "{path}_FILE",
+			want:     "PASS_FILE",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.title, func(t *testing.T) {
 			rule := Rule{
+				ParameterRule: ParameterRule{
+					Path:  tt.rulePath,
+					Level: tt.level,
+				},
 				DestinationRule: DestinationRule{
+					To: tt.to,
 					TypeEnvOptions: &DestinationTypeEnvOptions{
 						Prefix:     tt.prefix,
 						EntirePath: tt.entirePath,
@@ -106,3 +243,760 @@ func TestRuleBuildEnvName(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleExecuteTypeEnv(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/path/to/foo", Value: "foo"},
+			{Path: "/path/to/bar", Value: "bar"},
+			{Path: "/path/to/sub/buzz", Value: "buzz"},
+		},
+	}
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/path/to/",
+			Level: ParameterLevelUnder,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeEnv,
+			TypeEnvOptions: &DestinationTypeEnvOptions{
+				Prefix: "TEST_",
+			},
+		},
+	}
+
+	exported := NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	want := Env{
+		"TEST_FOO": "foo",
+		"TEST_BAR": "bar",
+	}
+
+	if diff := cmp.Diff(want, exported.Env); diff != "" {
+		t.Errorf("Execute() has diff:\n%s", diff)
+	}
+}
+
+func TestRuleExecuteTypeEnvWithExpand(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/db", Value: `{"user":"app","password":"secret","replica":{"host":"replica.local"}}`},
+			{Path: "/prod/plain", Value: "plain"},
+		},
+	}
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/prod/db",
+			Level: ParameterLevelStrict,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeEnv,
+			TypeEnvOptions: &DestinationTypeEnvOptions{
+				Prefix: "APP_",
+				Expand: ExpansionJSON,
+			},
+		},
+	}
+
+	exported := NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	want := Env{
+		"APP_DB_USER":         "app",
+		"APP_DB_PASSWORD":     "secret",
+		"APP_DB_REPLICA_HOST": "replica.local",
+	}
+
+	if diff := cmp.Diff(want, exported.Env); diff != "" {
+		t.Errorf("Execute() has diff:\n%s", diff)
+	}
+
+	rule.ParameterRule.Path = "/prod/plain"
+
+	err := rule.Execute(store, NewExported())
+
+	var destinationErr *DestinationError
+	if !errors.As(err, &destinationErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if destinationErr.Address != "APP_PLAIN" {
+		t.Errorf("unexpected address: %s", destinationErr.Address)
+	}
+}
+
+func TestRuleExecuteList(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/hosts", Value: "a.local,b.local,c.local", Type: ParameterTypeStringList},
+			{Path: "/prod/host", Value: "a.local,b.local", Type: ParameterTypeString},
+		},
+	}
+
+	tests := []struct {
+		title string
+		path  string
+		list  ListMode
+		sep   string
+		want  Env
+	}{
+		{
+			title: "index",
+			path:  "/prod/hosts",
+			list:  ListModeIndex,
+			want: Env{
+				"HOSTS_0":     "a.local",
+				"HOSTS_1":     "b.local",
+				"HOSTS_2":     "c.local",
+				"HOSTS_COUNT": "3",
+			},
+		},
+		{
+			title: "index String",
+			path:  "/prod/host",
+			list:  ListModeIndex,
+			want: Env{
+				"HOST_0":     "a.local,b.local",
+				"HOST_COUNT": "1",
+			},
+		},
+		{
+			title: "join with default separator",
+			path:  "/prod/hosts",
+			list:  ListModeJoin,
+			want:  Env{"HOSTS": "a.local b.local c.local"},
+		},
+		{
+			title: "join with separator",
+			path:  "/prod/hosts",
+			list:  ListModeJoin,
+			sep:   "\n",
+			want:  Env{"HOSTS": "a.local\nb.local\nc.local"},
+		},
+		{
+			title: "none",
+			path:  "/prod/hosts",
+			want:  Env{"HOSTS": "a.local,b.local,c.local"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			rule := Rule{
+				ParameterRule: ParameterRule{
+					Path:  tt.path,
+					Level: ParameterLevelStrict,
+				},
+				DestinationRule: DestinationRule{
+					Type:           DestinationTypeEnv,
+					List:           tt.list,
+					ListSeparator:  tt.sep,
+					TypeEnvOptions: &DestinationTypeEnvOptions{},
+				},
+			}
+
+			exported := NewExported()
+			if err := rule.Execute(store, exported); err != nil {
+				t.Fatalf("failed to execute: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, exported.Env); diff != "" {
+				t.Errorf("Execute() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleExecuteTypeEnvWithJoin(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/app/allowed_hosts/b", Value: "b.local", LastModified: base},
+			{Path: "/prod/app/allowed_hosts/a", Value: "a.local", LastModified: base.Add(time.Hour)},
+			{Path: "/prod/app/allowed_hosts/sub/c", Value: "c.local", LastModified: base.Add(2 * time.Hour)},
+			{Path: "/prod/app/other", Value: "other"},
+		},
+	}
+
+	tests := []struct {
+		title string
+		level ParameterLevel
+		sep   string
+		order JoinOrder
+		want  string
+	}{
+		{
+			title: "under",
+			level: ParameterLevelUnder,
+			want:  "a.local b.local",
+		},
+		{
+			title: "all with separator",
+			level: ParameterLevelAll,
+			sep:   ",",
+			want:  "a.local,b.local,c.local",
+		},
+		{
+			title: "all by last modified",
+			level: ParameterLevelAll,
+			sep:   "\n",
+			order: JoinOrderLastModified,
+			want:  "b.local\na.local\nc.local",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			rule := Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/prod/app/allowed_hosts/",
+					Level: tt.level,
+				},
+				DestinationRule: DestinationRule{
+					Type: DestinationTypeEnv,
+					To:   "ALLOWED_HOSTS",
+					TypeEnvOptions: &DestinationTypeEnvOptions{
+						Join:          true,
+						JoinSeparator: tt.sep,
+						JoinOrder:     tt.order,
+					},
+				},
+			}
+
+			exported := NewExported()
+			if err := rule.Execute(store, exported); err != nil {
+				t.Fatalf("failed to execute: %s", err)
+			}
+
+			if diff := cmp.Diff(Env{"ALLOWED_HOSTS": tt.want}, exported.Env); diff != "" {
+				t.Errorf("Execute() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleExecuteSkipsMissingParameter(t *testing.T) {
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/path/to/missing",
+			Level: ParameterLevelStrict,
+		},
+		DestinationRule: DestinationRule{
+			Type:           DestinationTypeEnv,
+			TypeEnvOptions: &DestinationTypeEnvOptions{},
+		},
+	}
+
+	exported := NewExported()
+	if err := rule.Execute(ParameterStore{}, exported); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(exported.Env) != 0 {
+		t.Errorf("unexpected env: %v", exported.Env)
+	}
+}
+
+func TestRuleExecuteReturnsParameterNotFoundError(t *testing.T) {
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:     "/path/to/missing",
+			Level:    ParameterLevelStrict,
+			Required: true,
+		},
+		DestinationRule: DestinationRule{
+			Type:           DestinationTypeEnv,
+			TypeEnvOptions: &DestinationTypeEnvOptions{},
+		},
+	}
+
+	err := rule.Execute(ParameterStore{}, NewExported())
+
+	var notFoundErr *ParameterNotFoundError
+	if !errors.As(err, &notFoundErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if notFoundErr.Path != "/path/to/missing" {
+		t.Errorf("unexpected path: %s", notFoundErr.Path)
+	}
+}
+
+func TestRuleExecuteTypeBundle(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/path/to/foo", Value: "foo"},
+			{Path: "/path/to/db/host", Value: "db.local"},
+			{Path: "/path/to/db/port", Value: "5432"},
+			{Path: "/other/bar", Value: "bar"},
+		},
+	}
+
+	tests := []struct {
+		title string
+		level ParameterLevel
+		opts  DestinationTypeBundleOptions
+		want  string
+	}{
+		{
+			title: "dotenv",
+			level: ParameterLevelUnder,
+			opts: DestinationTypeBundleOptions{
+				Format:                    BundleFormatDotenv,
+				DestinationTypeEnvOptions: DestinationTypeEnvOptions{Prefix: "APP_"},
+			},
+			want: "APP_FOO=\"foo\"\n",
+		},
+		{
+			title: "json nested",
+			level: ParameterLevelAll,
+			opts: DestinationTypeBundleOptions{
+				Format: BundleFormatJSON,
+			},
+			want: `{
+  "DB": {
+    "HOST": "db.local",
+    "PORT": "5432"
+  },
+  "FOO": "foo"
+}
+`,
+		},
+		{
+			title: "yaml entire path",
+			level: ParameterLevelAll,
+			opts: DestinationTypeBundleOptions{
+				Format:                    BundleFormatYAML,
+				DestinationTypeEnvOptions: DestinationTypeEnvOptions{EntirePath: true},
+			},
+			want: `PATH:
+  TO:
+    DB:
+      HOST: "db.local"
+      PORT: "5432"
+    FOO: "foo"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			to := filepath.Join(t.TempDir(), "bundle")
+			opts := tt.opts
+
+			rule := Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/path/to/",
+					Level: tt.level,
+				},
+				DestinationRule: DestinationRule{
+					Type:              DestinationTypeBundle,
+					To:                to,
+					TypeBundleOptions: &opts,
+				},
+			}
+
+			exported := NewExported()
+			if err := rule.Execute(store, exported); err != nil {
+				t.Fatalf("failed to execute: %s", err)
+			}
+
+			if diff := cmp.Diff([]string{to}, exported.Files); diff != "" {
+				t.Errorf("exported files have diff:\n%s", diff)
+			}
+
+			got, err := os.ReadFile(to)
+			if err != nil {
+				t.Fatalf("failed to read bundle: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("bundle has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleExecuteTypeBundleReturnsDestinationError(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/a/param", Value: "a"},
+			{Path: "/b/param", Value: "b"},
+		},
+	}
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/",
+			Level: ParameterLevelAll,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeBundle,
+			To:   filepath.Join(t.TempDir(), "bundle"),
+			TypeBundleOptions: &DestinationTypeBundleOptions{
+				Format: BundleFormatDotenv,
+			},
+		},
+	}
+
+	err := rule.Execute(store, NewExported())
+
+	var destinationErr *DestinationError
+	if !errors.As(err, &destinationErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRuleExecuteDecode(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/path/to/keystore", Value: "AAH+/2tleQ=="},
+			{Path: "/path/to/broken", Value: "not base64!"},
+		},
+	}
+
+	to := filepath.Join(t.TempDir(), "keystore")
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/path/to/keystore",
+			Level: ParameterLevelStrict,
+		},
+		DestinationRule: DestinationRule{
+			Type:            DestinationTypeFile,
+			To:              to,
+			Decode:          DecodingBase64,
+			TypeFileOptions: &DestinationTypeFileOptions{},
+		},
+	}
+
+	if err := rule.Execute(store, NewExported()); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	got, err := os.ReadFile(to)
+	if err != nil {
+		t.Fatalf("failed to read file: %s", err)
+	}
+
+	if diff := cmp.Diff([]byte("\x00\x01\xfe\xffkey"), got); diff != "" {
+		t.Errorf("decoded content has diff:\n%s", diff)
+	}
+
+	rule.ParameterRule.Path = "/path/to/broken"
+
+	err = rule.Execute(store, NewExported())
+
+	var destinationErr *DestinationError
+	if !errors.As(err, &destinationErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if destinationErr.Address != to {
+		t.Errorf("unexpected address: %s", destinationErr.Address)
+	}
+}
+
+func TestRuleExecuteKey(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/db", Value: `{"user":"app","password":"secret","tls":{"cert":"CERT"}}`},
+		},
+	}
+
+	tests := []struct {
+		title       string
+		key         string
+		keyOptional bool
+		want        Env
+		wantErr     bool
+	}{
+		{
+			title: "field",
+			key:   ".password",
+			want:  Env{"DB": "secret"},
+		},
+		{
+			title: "nested field",
+			key:   ".tls.cert",
+			want:  Env{"DB": "CERT"},
+		},
+		{
+			title:   "missing field",
+			key:     ".tls.key",
+			wantErr: true,
+		},
+		{
+			title:       "missing optional field",
+			key:         ".tls.key",
+			keyOptional: true,
+			want:        Env{"DB": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			rule := Rule{
+				ParameterRule: ParameterRule{
+					Path:  "/prod/db",
+					Level: ParameterLevelStrict,
+				},
+				DestinationRule: DestinationRule{
+					Type:           DestinationTypeEnv,
+					Key:            tt.key,
+					KeyOptional:    tt.keyOptional,
+					TypeEnvOptions: &DestinationTypeEnvOptions{},
+				},
+			}
+
+			exported := NewExported()
+			err := rule.Execute(store, exported)
+
+			if tt.wantErr {
+				var destinationErr *DestinationError
+				if !errors.As(err, &destinationErr) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to execute: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, exported.Env); diff != "" {
+				t.Errorf("Execute() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleExecuteTypeFileWithMkdir(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/path/to/param", Value: "value"},
+		},
+	}
+
+	root := t.TempDir()
+	to := filepath.Join(root, "a", "b", "file")
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/path/to/param",
+			Level: ParameterLevelStrict,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeFile,
+			To:   to,
+			TypeFileOptions: &DestinationTypeFileOptions{
+				DirMode: 0700,
+			},
+		},
+	}
+
+	if err := rule.Execute(store, NewExported()); err == nil {
+		t.Fatalf("should be error without mkdir")
+	}
+
+	rule.DestinationRule.TypeFileOptions.Mkdir = true
+
+	exported := NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	got, err := os.ReadFile(to)
+	if err != nil {
+		t.Fatalf("failed to read file: %s", err)
+	}
+
+	if string(got) != "value" {
+		t.Errorf("unexpected content: %s", got)
+	}
+
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "a", "b")}
+	if diff := cmp.Diff(want, exported.Created); diff != "" {
+		t.Errorf("created directories have diff:\n%s", diff)
+	}
+
+	for _, dir := range want {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("failed to stat directory: %s", err)
+		}
+
+		if info.Mode().Perm() != 0700 {
+			t.Errorf("unexpected mode of %s: %04o", dir, info.Mode().Perm())
+		}
+	}
+
+	// directories already exist
+	exported = NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	if len(exported.Created) != 0 {
+		t.Errorf("no directories should be created: %v", exported.Created)
+	}
+}
+
+func TestRuleExecuteTypeDir(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/certs/tls.crt", Value: "cert"},
+			{Path: "/prod/certs/ca/root.crt", Value: "root"},
+			{Path: "/prod/other", Value: "other"},
+		},
+	}
+
+	to := filepath.Join(t.TempDir(), "secrets")
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/prod/certs/",
+			Level: ParameterLevelAll,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeDir,
+			To:   to,
+			TypeDirOptions: &DestinationTypeDirOptions{
+				DestinationTypeFileOptions: DestinationTypeFileOptions{
+					Mode:    0600,
+					DirMode: 0700,
+				},
+			},
+		},
+	}
+
+	exported := NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	want := map[string]string{
+		filepath.Join(to, "tls.crt"):        "cert",
+		filepath.Join(to, "ca", "root.crt"): "root",
+	}
+
+	for path, value := range want {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %s", path, err)
+		}
+
+		if string(got) != value {
+			t.Errorf("unexpected content of %s: %s", path, got)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %s", path, err)
+		}
+
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("unexpected mode of %s: %04o", path, info.Mode().Perm())
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(to, "ca"))
+	if err != nil {
+		t.Fatalf("failed to stat directory: %s", err)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("unexpected mode of directory: %04o", info.Mode().Perm())
+	}
+
+	if diff := cmp.Diff([]string{filepath.Join(to, "ca", "root.crt"), filepath.Join(to, "tls.crt")}, exported.Files); diff != "" {
+		t.Errorf("exported files have diff:\n%s", diff)
+	}
+}
+
+func TestRuleExecuteTypeDirRejectsPathOutsideOfDirectory(t *testing.T) {
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/prod/certs/../escape", Value: "escape"},
+		},
+	}
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/prod/certs/",
+			Level: ParameterLevelAll,
+		},
+		DestinationRule: DestinationRule{
+			Type:           DestinationTypeDir,
+			To:             filepath.Join(t.TempDir(), "secrets"),
+			TypeDirOptions: &DestinationTypeDirOptions{},
+		},
+	}
+
+	err := rule.Execute(store, NewExported())
+
+	var destinationErr *DestinationError
+	if !errors.As(err, &destinationErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRuleExecuteTypeTemplate(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.conf.tmpl")
+	to := filepath.Join(dir, "app.conf")
+
+	if err := os.WriteFile(src, []byte(`password={{ param "/app/db/password" }}`+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write template: %s", err)
+	}
+
+	rule := Rule{
+		ParameterRule: ParameterRule{
+			Path:  "/app/",
+			Level: ParameterLevelAll,
+		},
+		DestinationRule: DestinationRule{
+			Type: DestinationTypeTemplate,
+			To:   to,
+			TypeTemplateOptions: &DestinationTypeTemplateOptions{
+				Src: src,
+				DestinationTypeFileOptions: DestinationTypeFileOptions{
+					Mode: 0600,
+				},
+			},
+		},
+	}
+
+	store := ParameterStore{
+		Parameters: []Parameter{
+			{Path: "/app/db/password", Value: "secret"},
+		},
+	}
+
+	exported := NewExported()
+	if err := rule.Execute(store, exported); err != nil {
+		t.Fatalf("failed to execute: %s", err)
+	}
+
+	got, err := os.ReadFile(to)
+	if err != nil {
+		t.Fatalf("failed to read rendered file: %s", err)
+	}
+
+	if diff := cmp.Diff("password=secret\n", string(got)); diff != "" {
+		t.Errorf("rendered file has diff:\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{to}, exported.Files); diff != "" {
+		t.Errorf("exported files have diff:\n%s", diff)
+	}
+}
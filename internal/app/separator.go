@@ -0,0 +1,48 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultSeparator is a separator of values joined by ListModeJoin or DestinationTypeEnvOptions.Join.
+const defaultSeparator = " "
+
+// ParseSeparator parses separator of joined values with Go escape sequences like `\n`, `\t` and `\x2c` (comma).
+func ParseSeparator(s string) (string, error) {
+	sep := &strings.Builder{}
+
+	for rest := s; rest != ""; {
+		// strconv.UnquoteChar rejects unescaped quote
+		if rest[0] == '"' {
+			sep.WriteByte('"')
+			rest = rest[1:]
+			continue
+		}
+
+		r, multibyte, tail, err := strconv.UnquoteChar(rest, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid separator: %s", s)
+		}
+
+		if multibyte {
+			sep.WriteRune(r)
+		} else {
+			sep.WriteByte(byte(r))
+		}
+
+		rest = tail
+	}
+
+	return sep.String(), nil
+}
+
+// FormatSeparator formats sep to be parsed by ParseSeparator.
+// Comma is escaped because it separates options of rule.
+func FormatSeparator(sep string) string {
+	quoted := strconv.Quote(sep)
+	quoted = quoted[1 : len(quoted)-1]
+
+	return strings.ReplaceAll(quoted, ",", `\x2c`)
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSeparator(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: " ", want: " "},
+		{value: `\n`, want: "\n"},
+		{value: `;\t`, want: ";\t"},
+		{value: `\x2c`, want: ","},
+		{value: `"`, want: `"`},
+		{value: `\"`, want: `"`},
+		{value: `\\`, want: `\`},
+		{value: "→", want: "→"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSeparator(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseSeparator() has diff:\n%s", diff)
+			}
+
+			// round trip
+			again, err := ParseSeparator(FormatSeparator(got))
+			if err != nil {
+				t.Fatalf("failed to parse formatted separator: %s", err)
+			}
+
+			if again != got {
+				t.Errorf("round trip failed: %q", again)
+			}
+		})
+	}
+
+	if _, err := ParseSeparator(`\q`); err == nil {
+		t.Errorf("should be error for invalid escape")
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Shell is a kind of shell to evaluate exported environment variables.
+type Shell string
+
+const (
+	ShellBash       Shell = "bash"
+	ShellZsh        Shell = "zsh"
+	ShellFish       Shell = "fish"
+	ShellPowerShell Shell = "powershell"
+)
+
+var shellVariableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// powerShellQuoteReplacer doubles single quotes in PowerShell's single-quoted string.
+// PowerShell treats typographic single quotes as same as `'`.
+var powerShellQuoteReplacer = strings.NewReplacer(
+	"'", "''",
+	"‘", "‘‘",
+	"’", "’’",
+	"‚", "‚‚",
+	"‛", "‛‛",
+)
+
+// fishQuoteReplacer escapes `\` and `'` in fish's single-quoted string.
+var fishQuoteReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+)
+
+func ParseShell(s string) (Shell, error) {
+	switch sh := Shell(strings.ToLower(s)); sh {
+	case ShellBash, ShellZsh, ShellFish, ShellPowerShell:
+		return sh, nil
+	case "pwsh":
+		return ShellPowerShell, nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", s)
+	}
+}
+
+// Script returns statements to set env as environment variables in the shell.
+// Values are quoted so that they are restored exactly, including newlines, quotes and `$`.
+func (sh Shell) Script(env Env) (string, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	b := &strings.Builder{}
+
+	for _, name := range names {
+		stmt, err := sh.Statement(name, env[name])
+		if err != nil {
+			return "", &DestinationError{Address: name, Err: err}
+		}
+
+		b.WriteString(stmt)
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// Statement returns a statement to set an environment variable in the shell.
+func (sh Shell) Statement(name, value string) (string, error) {
+	if !shellVariableNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid variable name for %s: %q", sh, name)
+	}
+
+	switch sh {
+	case ShellBash, ShellZsh:
+		return fmt.Sprintf("export %s='%s'", name, strings.ReplaceAll(value, `'`, `'\''`)), nil
+	case ShellFish:
+		return fmt.Sprintf("set -gx %s '%s'", name, fishQuoteReplacer.Replace(value)), nil
+	case ShellPowerShell:
+		return fmt.Sprintf("$env:%s = '%s'", name, powerShellQuoteReplacer.Replace(value)), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", sh)
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShellStatement(t *testing.T) {
+	value := "it's \"$HOME\"\n`date` \\n"
+
+	tests := []struct {
+		shell Shell
+		want  string
+	}{
+		{
+			shell: ShellBash,
+			want:  "export FOO='it'\\''s \"$HOME\"\n`date` \\n'",
+		},
+		{
+			shell: ShellZsh,
+			want:  "export FOO='it'\\''s \"$HOME\"\n`date` \\n'",
+		},
+		{
+			shell: ShellFish,
+			want:  "set -gx FOO 'it\\'s \"$HOME\"\n`date` \\\\n'",
+		},
+		{
+			shell: ShellPowerShell,
+			want:  "$env:FOO = 'it''s \"$HOME\"\n`date` \\n'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.shell), func(t *testing.T) {
+			got, err := tt.shell.Statement("FOO", value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Statement() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestShellStatementReturnsError(t *testing.T) {
+	for _, name := range []string{"", "1FOO", "FOO-BAR", "FOO BAR", "FOO;rm"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ShellBash.Statement(name, "value"); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestShellScriptRoundTrip(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	env := Env{
+		"MULTI":  "line1\nline2\n",
+		"QUOTES": `'single' "double" 'it'\''s'`,
+		"DOLLAR": "$HOME ${HOME} $(echo x) `echo y`",
+		"EMPTY":  "",
+	}
+
+	script, err := ShellBash.Script(env)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Env{}
+	for name := range env {
+		out, err := exec.Command(bash, "-c", script+`printf '%s' "$`+name+`"`).Output()
+		if err != nil {
+			t.Fatalf("failed to evaluate script: %s", err)
+		}
+
+		got[name] = string(out)
+	}
+
+	if diff := cmp.Diff(env, got); diff != "" {
+		t.Errorf("values are not restored:\n%s", diff)
+	}
+}
+
+func TestParseShell(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Shell
+	}{
+		{s: "bash", want: ShellBash},
+		{s: "zsh", want: ShellZsh},
+		{s: "fish", want: ShellFish},
+		{s: "PowerShell", want: ShellPowerShell},
+		{s: "pwsh", want: ShellPowerShell},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseShell(tt.s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseShell("tcsh"); err == nil {
+		t.Errorf("expected error")
+	}
+}
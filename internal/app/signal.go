@@ -0,0 +1,42 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var signalNames = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"KILL":  syscall.SIGKILL,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"ALRM":  syscall.SIGALRM,
+	"TERM":  syscall.SIGTERM,
+	"CONT":  syscall.SIGCONT,
+	"WINCH": syscall.SIGWINCH,
+}
+
+// maxSignalNumber is the largest signal number, SIGRTMAX on Linux.
+const maxSignalNumber = 64
+
+// ParseSignal parses signal name like `HUP`, `SIGHUP` or `1`.
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil {
+		if n <= 0 || maxSignalNumber < n {
+			return 0, fmt.Errorf("invalid signal number: %d", n)
+		}
+
+		return syscall.Signal(n), nil
+	}
+
+	key := strings.TrimPrefix(strings.ToUpper(name), "SIG")
+	if sig, ok := signalNames[key]; ok {
+		return sig, nil
+	}
+
+	return 0, fmt.Errorf("unknown signal: %s", name)
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name string
+		want syscall.Signal
+	}{
+		{name: "HUP", want: syscall.SIGHUP},
+		{name: "SIGHUP", want: syscall.SIGHUP},
+		{name: "usr1", want: syscall.SIGUSR1},
+		{name: "15", want: syscall.SIGTERM},
+		{name: "64", want: syscall.Signal(64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSignal(tt.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSignalReturnsError(t *testing.T) {
+	for _, name := range []string{"", "0", "-1", "65", "999", "0x0f", "UNKNOWN"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSignal(name); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -3,21 +3,35 @@ package app
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 )
 
 type SSMConnector interface {
-	fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]string, error)
-	fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]string, error)
+	fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]Parameter, error)
+	fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]Parameter, error)
 }
 
-type DefaultSSMConnector struct{}
+type DefaultSSMConnector struct {
+	// RequestTimeout is timeout for each request to SSM.
+	// If RequestTimeout is 0, requests never time out by itself.
+	RequestTimeout time.Duration
+}
+
+func (c DefaultSSMConnector) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.RequestTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.RequestTimeout)
+}
 
-func (c DefaultSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]string, error) {
-	params := map[string]string{}
+func (c DefaultSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]Parameter, error) {
+	params := map[string]Parameter{}
 	if len(paths) == 0 {
 		return params, nil
 	}
@@ -36,13 +50,15 @@ func (c DefaultSSMConnector) fetchParametersByPaths(ctx context.Context, client
 				input.NextToken = aws.String(nextToken)
 			}
 
-			output, err := client.GetParametersByPath(ctx, input)
+			reqCtx, cancel := c.requestContext(ctx)
+			output, err := client.GetParametersByPath(reqCtx, input)
+			cancel()
 			if err != nil {
 				return params, fmt.Errorf("failed to GetParametersByPath: %w", err)
 			}
 
 			for _, param := range output.Parameters {
-				params[*param.Name] = *param.Value
+				params[*param.Name] = newParameter(param)
 			}
 
 			if output.NextToken == nil {
@@ -56,8 +72,8 @@ func (c DefaultSSMConnector) fetchParametersByPaths(ctx context.Context, client
 	return params, nil
 }
 
-func (c DefaultSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]string, error) {
-	params := make(map[string]string, len(names))
+func (c DefaultSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]Parameter, error) {
+	params := make(map[string]Parameter, len(names))
 	if len(names) == 0 {
 		return params, nil
 	}
@@ -72,18 +88,31 @@ func (c DefaultSSMConnector) fetchParametersByNames(ctx context.Context, client
 		input.Names = append(input.Names, name)
 	}
 
-	output, err := client.GetParameters(ctx, input)
+	reqCtx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	output, err := client.GetParameters(reqCtx, input)
 	if err != nil {
-		return params, fmt.Errorf("failed to GetParameters: %s", err)
+		return params, fmt.Errorf("failed to GetParameters: %w", err)
 	}
 
 	for _, param := range output.Parameters {
-		params[*param.Name] = *param.Value
+		params[*param.Name] = newParameter(param)
 	}
 
 	return params, nil
 }
 
+func newParameter(param types.Parameter) Parameter {
+	return Parameter{
+		Path:  aws.ToString(param.Name),
+		Value: aws.ToString(param.Value),
+		Type:  ParameterType(param.Type),
+
+		LastModified: aws.ToTime(param.LastModifiedDate),
+	}
+}
+
 func NewSSMClient(ctx context.Context, retries int) (*ssm.Client, error) {
 	opts := []func(*config.LoadOptions) error{}
 
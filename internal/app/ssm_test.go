@@ -12,10 +12,21 @@ import (
 
 type MockSSMConnector struct {
 	data map[string]string
+
+	// types is types of parameters in data. Parameters not in types have no type.
+	types map[string]ParameterType
+}
+
+func (c MockSSMConnector) parameter(key string) Parameter {
+	return Parameter{
+		Path:  key,
+		Value: c.data[key],
+		Type:  c.types[key],
+	}
 }
 
-func (c MockSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]string, error) {
-	ret := map[string]string{}
+func (c MockSSMConnector) fetchParametersByPaths(ctx context.Context, client *ssm.Client, paths []string, recursive bool) (map[string]Parameter, error) {
+	ret := map[string]Parameter{}
 	dataKeys := lo.Keys(c.data)
 
 	for _, path := range paths {
@@ -39,20 +50,19 @@ func (c MockSSMConnector) fetchParametersByPaths(ctx context.Context, client *ss
 		}
 
 		for _, key := range keys {
-			ret[key] = c.data[key]
+			ret[key] = c.parameter(key)
 		}
 	}
 
 	return ret, nil
 }
 
-func (c MockSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]string, error) {
-	ret := map[string]string{}
+func (c MockSSMConnector) fetchParametersByNames(ctx context.Context, client *ssm.Client, names []string) (map[string]Parameter, error) {
+	ret := map[string]Parameter{}
 
 	for _, name := range names {
-		v, ok := c.data[name]
-		if ok {
-			ret[name] = v
+		if _, ok := c.data[name]; ok {
+			ret[name] = c.parameter(name)
 		}
 	}
 
@@ -109,7 +119,11 @@ func TestMockSSMConnectorFetchParametersByPaths(t *testing.T) {
 				return
 			}
 
-			if diff := cmp.Diff(got, tt.want); diff != "" {
+			values := lo.MapValues(got, func(p Parameter, _ string) string {
+				return p.Value
+			})
+
+			if diff := cmp.Diff(values, tt.want); diff != "" {
 				t.Errorf("fetchParametersByPaths() has diff:\n%s", diff)
 			}
 		})
@@ -155,7 +169,11 @@ func TestMockSSMConnectorFetchParametersByNames(t *testing.T) {
 				return
 			}
 
-			if diff := cmp.Diff(got, tt.want); diff != "" {
+			values := lo.MapValues(got, func(p Parameter, _ string) string {
+				return p.Value
+			})
+
+			if diff := cmp.Diff(values, tt.want); diff != "" {
 				t.Errorf("fetchParametersByNames() has diff:\n%s", diff)
 			}
 		})
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+// forwardedSignals are signals that will be relayed to the supervised command.
+var forwardedSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+	syscall.SIGWINCH,
+}
+
+// ExitError reports that the supervised command exited with non-zero status.
+type ExitError struct {
+	// Code is exit status of the command.
+	// If the command was terminated by a signal, Code is 128 + signal number.
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+// Supervisor runs a command as a child process.
+// The command runs in its own process group, and signals are sent to the whole group.
+// If stdin is a terminal, the command stays in the process group of ssmwrap instead,
+// so that it can read from the terminal as a foreground process.
+type Supervisor struct {
+	// Command and arguments to run.
+	Command []string
+
+	// Env is environment variables passed to the command.
+	Env []string
+
+	// Reaper waits for the command instead of Supervisor if set.
+	// It is required when ssmwrap reaps orphaned processes as PID 1.
+	Reaper *Reaper
+
+	// group is true if the command runs in its own process group.
+	group bool
+
+	cmd    *exec.Cmd
+	pid    int
+	done   chan struct{}
+	status syscall.WaitStatus
+	err    error
+}
+
+func NewSupervisor(command []string, env []string) *Supervisor {
+	return &Supervisor{
+		Command: command,
+		Env:     env,
+		group:   !isatty.IsTerminal(os.Stdin.Fd()),
+	}
+}
+
+// Start starts the command. It does not wait for the command to exit.
+func (s *Supervisor) Start() error {
+	if len(s.Command) == 0 {
+		return fmt.Errorf("command required")
+	}
+
+	bin, err := exec.LookPath(s.Command[0])
+	if err != nil {
+		return &CommandError{Command: s.Command[0], Err: err}
+	}
+
+	cmd := &exec.Cmd{
+		Path:        bin,
+		Args:        s.Command,
+		Env:         s.Env,
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		SysProcAttr: s.sysProcAttr(),
+	}
+
+	s.cmd = cmd
+	s.done = make(chan struct{})
+
+	if s.Reaper != nil {
+		statusCh, err := s.Reaper.Spawn(func() (int, error) {
+			if err := cmd.Start(); err != nil {
+				return 0, err
+			}
+
+			return cmd.Process.Pid, nil
+		})
+		if err != nil {
+			return &CommandError{Command: s.Command[0], Err: err}
+		}
+
+		s.pid = cmd.Process.Pid
+
+		go func() {
+			s.status = <-statusCh
+			close(s.done)
+		}()
+	} else {
+		if err := cmd.Start(); err != nil {
+			return &CommandError{Command: s.Command[0], Err: err}
+		}
+
+		s.pid = cmd.Process.Pid
+
+		go func() {
+			err := cmd.Wait()
+
+			s.err = err
+			if cmd.ProcessState != nil {
+				if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+					s.status = ws
+					s.err = nil
+				}
+			}
+
+			close(s.done)
+		}()
+	}
+
+	slog.Debug("command started", slog.Int("pid", s.pid))
+
+	return nil
+}
+
+// sysProcAttr makes the command run in a new process group if group is true.
+// Otherwise, signals from the terminal are delivered to both of ssmwrap and the command.
+func (s *Supervisor) sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Setpgid: s.group,
+	}
+}
+
+// Signal sends sig to the process group of the command, or only to the command if it has no own group.
+// It does nothing after the command exited, not to signal a process reusing the pid.
+func (s *Supervisor) Signal(sig os.Signal) error {
+	if s.cmd == nil || s.pid == 0 {
+		return fmt.Errorf("command is not started")
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	ssig, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %s", sig)
+	}
+
+	target := s.pid
+	if s.group {
+		target = -s.pid
+	}
+
+	if err := syscall.Kill(target, ssig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("failed to send signal %s to command: %w", sig, err)
+	}
+
+	return nil
+}
+
+// Done returns a channel that is closed when the command exits.
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait waits for the command to exit.
+// If the command exits with non-zero status, Wait returns *ExitError.
+func (s *Supervisor) Wait() error {
+	<-s.done
+
+	if s.err != nil {
+		return fmt.Errorf("failed to wait command: %w", s.err)
+	}
+
+	code := s.status.ExitStatus()
+	if s.status.Signaled() {
+		code = 128 + int(s.status.Signal())
+	}
+
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSupervisorWait(t *testing.T) {
+	tests := []struct {
+		title   string
+		command []string
+		want    int
+	}{
+		{
+			title:   "success",
+			command: []string{"sh", "-c", "exit 0"},
+			want:    0,
+		},
+		{
+			title:   "failure",
+			command: []string{"sh", "-c", "exit 3"},
+			want:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			sv := NewSupervisor(tt.command, nil)
+			if err := sv.Start(); err != nil {
+				t.Fatalf("failed to start: %s", err)
+			}
+
+			err := sv.Wait()
+			if tt.want == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if exitErr.Code != tt.want {
+				t.Errorf("unexpected exit code: %d (expected %d)", exitErr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupervisorSignal(t *testing.T) {
+	sv := NewSupervisor([]string{"sleep", "10"}, nil)
+	if err := sv.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	if err := sv.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send signal: %s", err)
+	}
+
+	var exitErr *ExitError
+	if err := sv.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := 128 + int(syscall.SIGTERM); exitErr.Code != want {
+		t.Errorf("unexpected exit code: %d (expected %d)", exitErr.Code, want)
+	}
+}
+
+func TestSupervisorSignalWithoutProcessGroup(t *testing.T) {
+	// as same as stdin is a terminal
+	sv := NewSupervisor([]string{"sleep", "10"}, nil)
+	sv.group = false
+
+	if err := sv.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	if pgid, err := syscall.Getpgid(sv.pid); err != nil {
+		t.Fatalf("failed to get pgid: %s", err)
+	} else if pgid != syscall.Getpgrp() {
+		t.Errorf("command should stay in process group of ssmwrap: %d", pgid)
+	}
+
+	if err := sv.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send signal: %s", err)
+	}
+
+	var exitErr *ExitError
+	if err := sv.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := 128 + int(syscall.SIGTERM); exitErr.Code != want {
+		t.Errorf("unexpected exit code: %d (expected %d)", exitErr.Code, want)
+	}
+}
+
+func TestSupervisorSignalAfterExit(t *testing.T) {
+	reaper := NewReaper()
+
+	sv := NewSupervisor([]string{"true"}, nil)
+	sv.Reaper = reaper
+
+	if err := sv.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+
+	// wait for the process to exit, and deliver the status as Reaper.Run does
+	time.Sleep(300 * time.Millisecond)
+	reaper.reap()
+
+	if err := sv.Wait(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := sv.Signal(syscall.SIGTERM); err != nil {
+		t.Errorf("signal after exit should be ignored: %s", err)
+	}
+}
+
+func TestSupervisorStartReturnsError(t *testing.T) {
+	sv := NewSupervisor([]string{"/path/to/not/exist"}, nil)
+
+	err := sv.Start()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !strings.HasPrefix(err.Error(), "command is not executable") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// TemplateRenderer renders a Go text/template with parameters.
+type TemplateRenderer struct {
+	params map[string]string
+}
+
+func NewTemplateRenderer(params []Parameter) *TemplateRenderer {
+	m := make(map[string]string, len(params))
+	for _, p := range params {
+		m[p.Path] = p.Value
+	}
+
+	return &TemplateRenderer{
+		params: m,
+	}
+}
+
+// RenderFile renders the template file at src.
+// Dot of the template is a map of all parameters keyed by path.
+func (r TemplateRenderer) RenderFile(src string) (string, error) {
+	body, err := os.ReadFile(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %w", src, err)
+	}
+
+	return r.Render(filepath.Base(src), string(body))
+}
+
+// Render renders the template text.
+func (r TemplateRenderer) Render(name, text string) (string, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(r.funcs()).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, r.params); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+func (r TemplateRenderer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"param":         r.param,
+		"optionalParam": r.optionalParam,
+		"params":        r.paramsUnder,
+		"default":       templateDefault,
+		"b64dec":        templateB64dec,
+		"json":          templateJSON,
+		"toJson":        templateToJSON,
+		"toYaml":        templateToYAML,
+	}
+}
+
+// param returns value of the parameter at path.
+// It fails if the parameter does not exist.
+func (r TemplateRenderer) param(path string) (string, error) {
+	v, ok := r.params[path]
+	if !ok {
+		return "", &ParameterNotFoundError{Path: path}
+	}
+
+	return v, nil
+}
+
+// optionalParam returns value of the parameter at path, or empty string if it does not exist.
+func (r TemplateRenderer) optionalParam(path string) string {
+	return r.params[path]
+}
+
+// paramsUnder returns parameters under prefix keyed by path relative to prefix.
+func (r TemplateRenderer) paramsUnder(prefix string) map[string]string {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	m := map[string]string{}
+	for path, v := range r.params {
+		if strings.HasPrefix(path, prefix) {
+			m[strings.TrimPrefix(path, prefix)] = v
+		}
+	}
+
+	return m
+}
+
+// templateDefault returns v, or def if v is empty. e.g. `{{ optionalParam "/path" | default "value" }}`
+func templateDefault(def string, v string) string {
+	if v == "" {
+		return def
+	}
+
+	return v
+}
+
+func templateB64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// templateJSON parses s as JSON. e.g. `{{ (param "/path" | json).key }}`
+func templateJSON(s string) (any, error) {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+
+	return v, nil
+}
+
+func templateToJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode json: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// templateToYAML encodes v into YAML without trailing newline.
+func templateToYAML(v any) (string, error) {
+	s, err := encodeYAML(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode yaml: %w", err)
+	}
+
+	return strings.TrimSuffix(s, "\n"), nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateRendererRender(t *testing.T) {
+	renderer := NewTemplateRenderer([]Parameter{
+		{Path: "/app/db/host", Value: "db.local"},
+		{Path: "/app/db/port", Value: "5432"},
+		{Path: "/app/cert", Value: "LS0tCmNlcnQKLS0t"},
+		{Path: "/app/config", Value: `{"name":"app","ports":[80,443],"tls":{"enabled":true}}`},
+		{Path: "/app/multi", Value: "line1\nline2"},
+	})
+
+	tests := []struct {
+		title string
+		text  string
+		want  string
+	}{
+		{
+			title: "param",
+			text:  `host={{ param "/app/db/host" }}:{{ param "/app/db/port" }}`,
+			want:  "host=db.local:5432",
+		},
+		{
+			title: "multi line value",
+			text:  `{{ param "/app/multi" }}`,
+			want:  "line1\nline2",
+		},
+		{
+			title: "optional param with default",
+			text:  `user={{ optionalParam "/app/db/user" | default "root" }},host={{ optionalParam "/app/db/host" | default "localhost" }}`,
+			want:  "user=root,host=db.local",
+		},
+		{
+			title: "params",
+			text:  `{{ range $k, $v := params "/app/db" }}{{ $k }}={{ $v }};{{ end }}`,
+			want:  "host=db.local;port=5432;",
+		},
+		{
+			title: "b64dec",
+			text:  `{{ param "/app/cert" | b64dec }}`,
+			want:  "---\ncert\n---",
+		},
+		{
+			title: "json",
+			text:  `{{ $c := param "/app/config" | json }}{{ $c.name }} {{ index $c.ports 1 }} {{ $c.tls.enabled }}`,
+			want:  "app 443 true",
+		},
+		{
+			title: "toJson",
+			text:  `{{ params "/app/db" | toJson }}`,
+			want:  `{"host":"db.local","port":"5432"}`,
+		},
+		{
+			title: "toYaml",
+			text:  `{{ param "/app/config" | json | toYaml }}`,
+			want:  "name: \"app\"\nports:\n  - 80\n  - 443\ntls:\n  enabled: true",
+		},
+		{
+			title: "dot",
+			text:  `{{ index . "/app/db/host" }}`,
+			want:  "db.local",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got, err := renderer.Render(tt.title, tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Render() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTemplateRendererRenderReturnsError(t *testing.T) {
+	renderer := NewTemplateRenderer([]Parameter{
+		{Path: "/app/invalid", Value: "not json"},
+	})
+
+	t.Run("missing param", func(t *testing.T) {
+		_, err := renderer.Render("missing", `{{ param "/app/missing" }}`)
+
+		var notFoundErr *ParameterNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if notFoundErr.Path != "/app/missing" {
+			t.Errorf("unexpected path: %s", notFoundErr.Path)
+		}
+	})
+
+	for title, text := range map[string]string{
+		"invalid json":   `{{ param "/app/invalid" | json }}`,
+		"invalid base64": `{{ param "/app/invalid" | b64dec }}`,
+		"syntax error":   `{{ param "/app/invalid" `,
+	} {
+		t.Run(title, func(t *testing.T) {
+			if _, err := renderer.Render(title, text); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var (
+	yamlPlainKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+	// yamlReservedWords are plain scalars which are not strings in YAML 1.1.
+	yamlReservedWords = []string{"y", "yes", "n", "no", "true", "false", "on", "off", "null"}
+)
+
+// encodeYAML encodes v in YAML block style.
+// v is normalized through JSON, so it can be any value which can be encoded into JSON.
+// Keys of maps are sorted, and strings are always double-quoted.
+func encodeYAML(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var normalized any
+	if err := dec.Decode(&normalized); err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+
+	switch normalized.(type) {
+	case map[string]any, []any:
+		if isEmptyYAMLCollection(normalized) {
+			buf.WriteString(yamlScalar(normalized) + "\n")
+		} else {
+			writeYAML(buf, normalized, 0)
+		}
+	default:
+		buf.WriteString(yamlScalar(normalized) + "\n")
+	}
+
+	return buf.String(), nil
+}
+
+func writeYAML(buf *bytes.Buffer, v any, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			writeYAMLEntry(buf, indent+yamlKey(k)+":", v[k], depth)
+		}
+	case []any:
+		for _, item := range v {
+			writeYAMLEntry(buf, indent+"-", item, depth)
+		}
+	}
+}
+
+// writeYAMLEntry writes a map entry or a list item, which starts with head.
+// Non-empty collections are written in following lines, indented deeper than head.
+func writeYAMLEntry(buf *bytes.Buffer, head string, v any, depth int) {
+	switch v.(type) {
+	case map[string]any, []any:
+		if !isEmptyYAMLCollection(v) {
+			buf.WriteString(head + "\n")
+			writeYAML(buf, v, depth+1)
+			return
+		}
+	}
+
+	fmt.Fprintf(buf, "%s %s\n", head, yamlScalar(v))
+}
+
+func isEmptyYAMLCollection(v any) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func yamlScalar(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlQuote(v)
+	case map[string]any:
+		return "{}"
+	case []any:
+		return "[]"
+	default:
+		return yamlQuote(fmt.Sprint(v))
+	}
+}
+
+func yamlKey(k string) string {
+	if yamlPlainKeyRegexp.MatchString(k) && !lo.Contains(yamlReservedWords, strings.ToLower(k)) {
+		return k
+	}
+
+	return yamlQuote(k)
+}
+
+// yamlQuote quotes v as a double-quoted scalar.
+// A JSON string is also valid as a YAML double-quoted scalar.
+func yamlQuote(v string) string {
+	buf := &bytes.Buffer{}
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v) // encoding a string never fails
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
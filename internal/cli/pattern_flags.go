@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PatternFlags is a flag accepts glob patterns multiple times.
+type PatternFlags struct {
+	Patterns []string
+}
+
+func (f PatternFlags) String() string {
+	return strings.Join(f.Patterns, ",")
+}
+
+func (f *PatternFlags) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("pattern must not be empty")
+	}
+
+	if _, err := path.Match(value, ""); err != nil {
+		return fmt.Errorf("invalid pattern %s: %w", value, err)
+	}
+
+	f.Patterns = append(f.Patterns, value)
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPatternFlagsSet(t *testing.T) {
+	var f PatternFlags
+
+	for _, v := range []string{"PATH", "AWS_*"} {
+		if err := f.Set(v); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"PATH", "AWS_*"}, f.Patterns); diff != "" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+}
+
+func TestPatternFlagsSetReturnsError(t *testing.T) {
+	for _, v := range []string{"", "AWS_["} {
+		var f PatternFlags
+		if err := f.Set(v); err == nil {
+			t.Errorf("should be error: %q", v)
+		}
+	}
+}
@@ -77,23 +77,22 @@ func (f RuleFlags) buildRule(opts map[string]string) (*app.Rule, error) {
 			return nil, err
 		}
 
-		rule.DestinationRule = app.DestinationRule{
-			Type:           app.DestinationTypeEnv,
-			To:             opts["to"],
-			TypeEnvOptions: &app.DestinationTypeEnvOptions{},
-		}
-
-		if v, ok := opts["prefix"]; ok {
-			rule.DestinationRule.TypeEnvOptions.Prefix = v
+		envOpts, err := f.buildEnvOptions(opts)
+		if err != nil {
+			return nil, err
 		}
 
-		if v, ok := opts["entirepath"]; ok {
-			entirePath, err := strconv.ParseBool(v)
-			if err != nil {
-				return nil, fmt.Errorf("invalid `entirepath`")
+		// each parameter must be exported to its own variable, unless joined
+		if to, ok := opts["to"]; ok && rule.ParameterRule.Level != app.ParameterLevelStrict && !envOpts.Join {
+			if !app.IsEnvNameTemplate(to) {
+				return nil, fmt.Errorf("`to` must contain `{name}` or `{path}` for `path` end with `/*` or `/**/*`")
 			}
+		}
 
-			rule.DestinationRule.TypeEnvOptions.EntirePath = entirePath
+		rule.DestinationRule = app.DestinationRule{
+			Type:           app.DestinationTypeEnv,
+			To:             opts["to"],
+			TypeEnvOptions: envOpts,
 		}
 	case string(app.DestinationTypeFile):
 		if err := f.checkOptionsCombinations(app.DestinationTypeFile, opts); err != nil {
@@ -110,71 +109,398 @@ func (f RuleFlags) buildRule(opts map[string]string) (*app.Rule, error) {
 
 		// TODO: check if `to` is valid as file path
 
+		fileOpts, err := f.buildFileOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+
 		rule.DestinationRule = app.DestinationRule{
 			Type:            app.DestinationTypeFile,
 			To:              opts["to"],
-			TypeFileOptions: &app.DestinationTypeFileOptions{},
+			TypeFileOptions: fileOpts,
+		}
+	case string(app.DestinationTypeBundle):
+		if err := f.checkOptionsCombinations(app.DestinationTypeBundle, opts); err != nil {
+			return nil, err
 		}
 
-		if modeStr, ok := opts["mode"]; ok {
-			mode, err := strconv.ParseUint(modeStr, 8, 32)
-			if err != nil {
-				return nil, fmt.Errorf("invalid `mode`")
-			}
+		if _, ok := opts["to"]; !ok {
+			return nil, fmt.Errorf("`to` is required for `type=bundle`")
+		}
 
-			rule.DestinationRule.TypeFileOptions.Mode = fs.FileMode(mode)
+		if _, ok := opts["format"]; !ok {
+			return nil, fmt.Errorf("`format` is required for `type=bundle`")
 		}
 
-		if uidStr, ok := opts["uid"]; ok {
-			uid, err := strconv.Atoi(uidStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid `uid`")
-			}
+		format, err := app.ParseBundleFormat(opts["format"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid `format`")
+		}
 
-			rule.DestinationRule.TypeFileOptions.Uid = uid
+		envOpts, err := f.buildEnvOptions(opts)
+		if err != nil {
+			return nil, err
 		}
 
-		if gidStr, ok := opts["gid"]; ok {
-			gid, err := strconv.Atoi(gidStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid `gid`")
-			}
+		fileOpts, err := f.buildFileOptions(opts)
+		if err != nil {
+			return nil, err
+		}
 
-			rule.DestinationRule.TypeFileOptions.Gid = gid
+		rule.DestinationRule = app.DestinationRule{
+			Type: app.DestinationTypeBundle,
+			To:   opts["to"],
+			TypeBundleOptions: &app.DestinationTypeBundleOptions{
+				Format:                     format,
+				DestinationTypeEnvOptions:  *envOpts,
+				DestinationTypeFileOptions: *fileOpts,
+			},
+		}
+	case string(app.DestinationTypeTemplate):
+		if err := f.checkOptionsCombinations(app.DestinationTypeTemplate, opts); err != nil {
+			return nil, err
+		}
+
+		if _, ok := opts["src"]; !ok {
+			return nil, fmt.Errorf("`src` is required for `type=template`")
+		}
+
+		if _, ok := opts["to"]; !ok {
+			return nil, fmt.Errorf("`to` is required for `type=template`")
+		}
+
+		fileOpts, err := f.buildFileOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.DestinationRule = app.DestinationRule{
+			Type: app.DestinationTypeTemplate,
+			To:   opts["to"],
+			TypeTemplateOptions: &app.DestinationTypeTemplateOptions{
+				Src:                        opts["src"],
+				DestinationTypeFileOptions: *fileOpts,
+			},
+		}
+	case string(app.DestinationTypeDir):
+		if err := f.checkOptionsCombinations(app.DestinationTypeDir, opts); err != nil {
+			return nil, err
+		}
+
+		if _, ok := opts["to"]; !ok {
+			return nil, fmt.Errorf("`to` is required for `type=dir`")
+		}
+
+		fileOpts, err := f.buildFileOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.DestinationRule = app.DestinationRule{
+			Type: app.DestinationTypeDir,
+			To:   opts["to"],
+			TypeDirOptions: &app.DestinationTypeDirOptions{
+				DestinationTypeFileOptions: *fileOpts,
+			},
 		}
 	default:
 		return nil, fmt.Errorf("invalid `type`")
 	}
 
+	if v, ok := opts["decode"]; ok {
+		decoding, err := app.ParseDecoding(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `decode`")
+		}
+
+		rule.DestinationRule.Decode = decoding
+	}
+
+	if v, ok := opts["key"]; ok {
+		if _, err := app.ParseKeySelector(v); err != nil {
+			return nil, fmt.Errorf("invalid `key`: %s", err)
+		}
+
+		rule.DestinationRule.Key = v
+	}
+
+	if v, ok := opts["list"]; ok {
+		list, err := app.ParseListMode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `list`")
+		}
+
+		if list == app.ListModeIndex && rule.DestinationRule.Type != app.DestinationTypeEnv {
+			return nil, fmt.Errorf("`list=index` is only allowed for `type=env`")
+		}
+
+		for _, key := range []string{"decode", "key", "expand"} {
+			if _, ok := opts[key]; ok {
+				return nil, fmt.Errorf("`list` cannot be used with `%s`", key)
+			}
+		}
+
+		rule.DestinationRule.List = list
+	}
+
+	if v, ok := opts["listsep"]; ok {
+		if opts["list"] != string(app.ListModeJoin) {
+			return nil, fmt.Errorf("`listsep` requires `list=join`")
+		}
+
+		sep, err := app.ParseSeparator(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `listsep`")
+		}
+
+		rule.DestinationRule.ListSeparator = sep
+	}
+
+	if v, ok := opts["keyoptional"]; ok {
+		if _, ok := opts["key"]; !ok {
+			return nil, fmt.Errorf("`keyoptional` requires `key`")
+		}
+
+		keyOptional, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `keyoptional`")
+		}
+
+		rule.DestinationRule.KeyOptional = keyOptional
+	}
+
+	if v, ok := opts["required"]; ok {
+		required, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `required`")
+		}
+
+		rule.ParameterRule.Required = required
+	}
+
 	return rule, nil
 }
 
-func (f RuleFlags) checkOptionsCombinations(t app.DestinationType, opts map[string]string) error {
-	for _, key := range lo.Keys(opts) {
-		switch key {
-		case "prefix":
-			if t != app.DestinationTypeEnv {
-				return f.Errorf(key, "`prefix` is only allowed for `type=env`")
-			}
-		case "entirepath":
-			if t != app.DestinationTypeEnv {
-				return f.Errorf(key, "`entirepath` is only allowed for `type=env`")
+// buildEnvOptions builds options to name environment variables, used by `type=env` and `type=bundle`.
+func (f RuleFlags) buildEnvOptions(opts map[string]string) (*app.DestinationTypeEnvOptions, error) {
+	envOpts := &app.DestinationTypeEnvOptions{}
+
+	if v, ok := opts["prefix"]; ok {
+		envOpts.Prefix = v
+	}
+
+	if v, ok := opts["entirepath"]; ok {
+		entirePath, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `entirepath`")
+		}
+
+		envOpts.EntirePath = entirePath
+	}
+
+	if v, ok := opts["expand"]; ok {
+		expand, err := app.ParseExpansion(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `expand`")
+		}
+
+		envOpts.Expand = expand
+	}
+
+	if v, ok := opts["join"]; ok {
+		join, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `join`")
+		}
+
+		if join {
+			if opts["to"] == "" {
+				return nil, fmt.Errorf("`to` is required for `join`")
 			}
 
-			if _, ok := opts["to"]; ok {
-				return f.Errorf(key, "can't use `to` with `entirepath` in same time")
+			if _, ok := opts["expand"]; ok {
+				return nil, fmt.Errorf("`join` cannot be used with `expand`")
 			}
-		case "mode":
-			if t != app.DestinationTypeFile {
-				return f.Errorf(key, "`mode` is only allowed for `type=file`")
+
+			if opts["list"] == string(app.ListModeIndex) {
+				return nil, fmt.Errorf("`join` cannot be used with `list=index`")
 			}
-		case "uid":
-			if t != app.DestinationTypeFile {
-				return f.Errorf(key, "`uid` is only allowed for `type=file`")
+		}
+
+		envOpts.Join = join
+	}
+
+	if v, ok := opts["joinsep"]; ok {
+		if _, ok := opts["join"]; !ok {
+			return nil, fmt.Errorf("`joinsep` requires `join`")
+		}
+
+		sep, err := app.ParseSeparator(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `joinsep`")
+		}
+
+		envOpts.JoinSeparator = sep
+	}
+
+	if v, ok := opts["joinorder"]; ok {
+		if _, ok := opts["join"]; !ok {
+			return nil, fmt.Errorf("`joinorder` requires `join`")
+		}
+
+		order, err := app.ParseJoinOrder(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `joinorder`")
+		}
+
+		envOpts.JoinOrder = order
+	}
+
+	if v, ok := opts["expandsep"]; ok {
+		if _, ok := opts["expand"]; !ok {
+			return nil, fmt.Errorf("`expandsep` requires `expand`")
+		}
+
+		envOpts.ExpandSeparator = v
+	}
+
+	return envOpts, nil
+}
+
+// buildFileOptions builds options to write files, used by all types writing files.
+func (f RuleFlags) buildFileOptions(opts map[string]string) (*app.DestinationTypeFileOptions, error) {
+	fileOpts := &app.DestinationTypeFileOptions{}
+
+	if modeStr, ok := opts["mode"]; ok {
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `mode`")
+		}
+
+		fileOpts.Mode = fs.FileMode(mode)
+	}
+
+	if uidStr, ok := opts["uid"]; ok {
+		uid, err := strconv.Atoi(uidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `uid`")
+		}
+
+		fileOpts.Uid = uid
+	}
+
+	if gidStr, ok := opts["gid"]; ok {
+		gid, err := strconv.Atoi(gidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `gid`")
+		}
+
+		fileOpts.Gid = gid
+	}
+
+	if v, ok := opts["cleanup"]; ok {
+		cleanup, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `cleanup`")
+		}
+
+		fileOpts.Cleanup = cleanup
+	}
+
+	if v, ok := opts["followsymlink"]; ok {
+		followSymlink, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `followsymlink`")
+		}
+
+		fileOpts.FollowSymlink = followSymlink
+	}
+
+	if v, ok := opts["mkdir"]; ok {
+		mkdir, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `mkdir`")
+		}
+
+		fileOpts.Mkdir = mkdir
+	}
+
+	if modeStr, ok := opts["dirmode"]; ok {
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `dirmode`")
+		}
+
+		fileOpts.DirMode = fs.FileMode(mode)
+	}
+
+	if uidStr, ok := opts["diruid"]; ok {
+		uid, err := strconv.Atoi(uidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `diruid`")
+		}
+
+		fileOpts.DirUid = uid
+	}
+
+	if gidStr, ok := opts["dirgid"]; ok {
+		gid, err := strconv.Atoi(gidStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `dirgid`")
+		}
+
+		fileOpts.DirGid = gid
+	}
+
+	return fileOpts, nil
+}
+
+// optionDestinationTypes is destination types which each option is allowed for.
+// Options not listed here are allowed for all types.
+var optionDestinationTypes = map[string][]app.DestinationType{
+	"prefix":     {app.DestinationTypeEnv, app.DestinationTypeBundle},
+	"entirepath": {app.DestinationTypeEnv, app.DestinationTypeBundle},
+	"mode":       {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"uid":        {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"gid":        {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"cleanup":    {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"format":     {app.DestinationTypeBundle},
+
+	"followsymlink": {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate},
+	"src":           {app.DestinationTypeTemplate},
+	"expand":        {app.DestinationTypeEnv},
+	"expandsep":     {app.DestinationTypeEnv},
+	"join":          {app.DestinationTypeEnv},
+	"joinsep":       {app.DestinationTypeEnv},
+	"joinorder":     {app.DestinationTypeEnv},
+	"decode":        {app.DestinationTypeEnv, app.DestinationTypeFile, app.DestinationTypeDir},
+	"key":           {app.DestinationTypeEnv, app.DestinationTypeFile, app.DestinationTypeDir},
+	"keyoptional":   {app.DestinationTypeEnv, app.DestinationTypeFile, app.DestinationTypeDir},
+	"list":          {app.DestinationTypeEnv, app.DestinationTypeFile, app.DestinationTypeDir},
+	"listsep":       {app.DestinationTypeEnv, app.DestinationTypeFile, app.DestinationTypeDir},
+	"mkdir":         {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate},
+	"dirmode":       {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"diruid":        {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+	"dirgid":        {app.DestinationTypeFile, app.DestinationTypeBundle, app.DestinationTypeTemplate, app.DestinationTypeDir},
+}
+
+func (f RuleFlags) checkOptionsCombinations(t app.DestinationType, opts map[string]string) error {
+	for _, key := range lo.Keys(opts) {
+		if types, ok := optionDestinationTypes[key]; ok && !lo.Contains(types, t) {
+			names := lo.Map(types, func(t app.DestinationType, _ int) string {
+				return "`type=" + string(t) + "`"
+			})
+
+			if 1 < len(names) {
+				names = append(names[:len(names)-2], names[len(names)-2]+" or "+names[len(names)-1])
 			}
-		case "gid":
-			if t != app.DestinationTypeFile {
-				return f.Errorf(key, "`gid` is only allowed for `type=file`")
+
+			return f.Errorf(key, "`%s` is only allowed for %s", key, strings.Join(names, ", "))
+		}
+
+		if (key == "entirepath" || key == "prefix") && t == app.DestinationTypeEnv {
+			if _, ok := opts["to"]; ok {
+				return f.Errorf(key, "can't use `to` with `%s` in same time", key)
 			}
 		}
 	}
@@ -124,6 +124,272 @@ func TestRuleFlagsSetSuccess(t *testing.T) {
 				},
 			},
 		},
+		{
+			title: "type file with cleanup",
+			value: "path=/path/to/param,type=file,to=/path/to/file,cleanup=true",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeFile,
+					To:   "/path/to/file",
+					TypeFileOptions: &app.DestinationTypeFileOptions{
+						Cleanup: true,
+					},
+				},
+			},
+		},
+		{
+			title: "type env with expand",
+			value: "path=/path/to/param,type=env,expand=json,expandsep=__",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeEnv,
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{
+						Expand:          app.ExpansionJSON,
+						ExpandSeparator: "__",
+					},
+				},
+			},
+		},
+		{
+			title: "type file with key",
+			value: "path=/path/to/param,type=file,to=/path/to/file,key=.tls.certs[0],keyoptional=true",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:            app.DestinationTypeFile,
+					To:              "/path/to/file",
+					Key:             ".tls.certs[0]",
+					KeyOptional:     true,
+					TypeFileOptions: &app.DestinationTypeFileOptions{},
+				},
+			},
+		},
+		{
+			title: "required",
+			value: "path=/path/to/param,type=env,required=true",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:     "/path/to/param",
+					Level:    app.ParameterLevelStrict,
+					Required: true,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:           app.DestinationTypeEnv,
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{},
+				},
+			},
+		},
+		{
+			title: "type env with list",
+			value: "path=/path/to/param,type=env,list=index",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:           app.DestinationTypeEnv,
+					List:           app.ListModeIndex,
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{},
+				},
+			},
+		},
+		{
+			title: "type file with list",
+			value: `path=/path/to/param,type=file,to=/path/to/file,list=join,listsep=\n`,
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:            app.DestinationTypeFile,
+					To:              "/path/to/file",
+					List:            app.ListModeJoin,
+					ListSeparator:   "\n",
+					TypeFileOptions: &app.DestinationTypeFileOptions{},
+				},
+			},
+		},
+		{
+			title: "type env with join",
+			value: `path=/path/to/hosts/*,type=env,to=HOSTS,join=true,joinsep=\x2c,joinorder=lastmodified`,
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/hosts/",
+					Level: app.ParameterLevelUnder,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeEnv,
+					To:   "HOSTS",
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{
+						Join:          true,
+						JoinSeparator: ",",
+						JoinOrder:     app.JoinOrderLastModified,
+					},
+				},
+			},
+		},
+		{
+			title: "type env with to",
+			value: "path=/path/to/param,type=env,to=DATABASE_PASSWORD",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:           app.DestinationTypeEnv,
+					To:             "DATABASE_PASSWORD",
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{},
+				},
+			},
+		},
+		{
+			title: "type env with to template",
+			value: "path=/path/to/**/*,type=env,to=APP_{path}",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/",
+					Level: app.ParameterLevelAll,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:           app.DestinationTypeEnv,
+					To:             "APP_{path}",
+					TypeEnvOptions: &app.DestinationTypeEnvOptions{},
+				},
+			},
+		},
+		{
+			title: "type file with decode",
+			value: "path=/path/to/param,type=file,to=/path/to/file,decode=gzip+base64",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type:            app.DestinationTypeFile,
+					To:              "/path/to/file",
+					Decode:          app.DecodingGzipBase64,
+					TypeFileOptions: &app.DestinationTypeFileOptions{},
+				},
+			},
+		},
+		{
+			title: "type file with mkdir",
+			value: "path=/path/to/param,type=file,to=/path/to/file,mkdir=true,dirmode=0700,diruid=1000,dirgid=2000",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeFile,
+					To:   "/path/to/file",
+					TypeFileOptions: &app.DestinationTypeFileOptions{
+						Mkdir:   true,
+						DirMode: 0700,
+						DirUid:  1000,
+						DirGid:  2000,
+					},
+				},
+			},
+		},
+		{
+			title: "type file with followsymlink",
+			value: "path=/path/to/param,type=file,to=/path/to/file,followsymlink=true",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/to/param",
+					Level: app.ParameterLevelStrict,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeFile,
+					To:   "/path/to/file",
+					TypeFileOptions: &app.DestinationTypeFileOptions{
+						FollowSymlink: true,
+					},
+				},
+			},
+		},
+		{
+			title: "type bundle with options",
+			value: "path=/path/all/**/*,type=bundle,to=/path/to/file,format=yaml,prefix=PREFIX_,entirepath=true,mode=0600",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/all/",
+					Level: app.ParameterLevelAll,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeBundle,
+					To:   "/path/to/file",
+					TypeBundleOptions: &app.DestinationTypeBundleOptions{
+						Format: app.BundleFormatYAML,
+						DestinationTypeEnvOptions: app.DestinationTypeEnvOptions{
+							Prefix:     "PREFIX_",
+							EntirePath: true,
+						},
+						DestinationTypeFileOptions: app.DestinationTypeFileOptions{
+							Mode: 0600,
+						},
+					},
+				},
+			},
+		},
+		{
+			title: "type template",
+			value: "path=/path/all/**/*,type=template,src=/path/to/tmpl,to=/path/to/file,cleanup=true",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/all/",
+					Level: app.ParameterLevelAll,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeTemplate,
+					To:   "/path/to/file",
+					TypeTemplateOptions: &app.DestinationTypeTemplateOptions{
+						Src: "/path/to/tmpl",
+						DestinationTypeFileOptions: app.DestinationTypeFileOptions{
+							Cleanup: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			title: "type dir",
+			value: "path=/path/all/**/*,type=dir,to=/path/to/dir,mode=0600,dirmode=0700,diruid=1000,dirgid=2000",
+			want: app.Rule{
+				ParameterRule: app.ParameterRule{
+					Path:  "/path/all/",
+					Level: app.ParameterLevelAll,
+				},
+				DestinationRule: app.DestinationRule{
+					Type: app.DestinationTypeDir,
+					To:   "/path/to/dir",
+					TypeDirOptions: &app.DestinationTypeDirOptions{
+						DestinationTypeFileOptions: app.DestinationTypeFileOptions{
+							Mode:    0600,
+							DirMode: 0700,
+							DirUid:  1000,
+							DirGid:  2000,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -176,6 +442,156 @@ func TestRuleFlagsSetReturnsError(t *testing.T) {
 			value: "path=/path/to/param/**/*,type=file,to=/path/to/file",
 			err:   "not allowed for `type=file`",
 		},
+		{
+			title: "to: required for `type=bundle`",
+			value: "path=/path/to/*,type=bundle,format=json",
+			err:   "`to` is required for `type=bundle`",
+		},
+		{
+			title: "format: required for `type=bundle`",
+			value: "path=/path/to/*,type=bundle,to=/path/to/file",
+			err:   "`format` is required for `type=bundle`",
+		},
+		{
+			title: "format: unsupported",
+			value: "path=/path/to/*,type=bundle,to=/path/to/file,format=xml",
+			err:   "invalid `format`",
+		},
+		{
+			title: "src: required for `type=template`",
+			value: "path=/path/to/*,type=template,to=/path/to/file",
+			err:   "`src` is required for `type=template`",
+		},
+		{
+			title: "to: required for `type=dir`",
+			value: "path=/path/to/*,type=dir",
+			err:   "`to` is required for `type=dir`",
+		},
+		{
+			title: "dirmode: invalid",
+			value: "path=/path/to/*,type=dir,to=/path/to/dir,dirmode=rwx",
+			err:   "invalid `dirmode`",
+		},
+		{
+			title: "dirmode: not for `type=env`",
+			value: "path=/path/to/param,type=env,dirmode=0700",
+			err:   "`dirmode` is only allowed for",
+		},
+		{
+			title: "mkdir: not for `type=dir`",
+			value: "path=/path/to/param,type=dir,to=/path/to/dir,mkdir=true",
+			err:   "`mkdir` is only allowed for `type=file`, `type=bundle` or `type=template`",
+		},
+		{
+			title: "expand: invalid value",
+			value: "path=/path/to/param,type=env,expand=yaml",
+			err:   "invalid `expand`",
+		},
+		{
+			title: "expand: only for `type=env`",
+			value: "path=/path/to/param,type=file,to=/path/to/file,expand=json",
+			err:   "`expand` is only allowed for `type=env`",
+		},
+		{
+			title: "expandsep: requires expand",
+			value: "path=/path/to/param,type=env,expandsep=__",
+			err:   "`expandsep` requires `expand`",
+		},
+		{
+			title: "key: invalid selector",
+			value: "path=/path/to/param,type=env,key=password",
+			err:   "invalid `key`",
+		},
+		{
+			title: "key: not for `type=template`",
+			value: "path=/path/to/*,type=template,to=/path/to/file,src=/path/to/src,key=.a",
+			err:   "`key` is only allowed for",
+		},
+		{
+			title: "keyoptional: requires key",
+			value: "path=/path/to/param,type=env,keyoptional=true",
+			err:   "`keyoptional` requires `key`",
+		},
+		{
+			title: "required: invalid value",
+			value: "path=/path/to/param,type=env,required=yes",
+			err:   "invalid `required`",
+		},
+		{
+			title: "list: invalid value",
+			value: "path=/path/to/param,type=env,list=split",
+			err:   "invalid `list`",
+		},
+		{
+			title: "list: index only for `type=env`",
+			value: "path=/path/to/param,type=file,to=/path/to/file,list=index",
+			err:   "`list=index` is only allowed for `type=env`",
+		},
+		{
+			title: "list: not with key",
+			value: "path=/path/to/param,type=env,list=join,key=.a",
+			err:   "`list` cannot be used with `key`",
+		},
+		{
+			title: "listsep: requires join",
+			value: "path=/path/to/param,type=env,list=index,listsep=;",
+			err:   "`listsep` requires `list=join`",
+		},
+		{
+			title: "to: requires placeholder for wildcard",
+			value: "path=/path/to/*,type=env,to=NAME",
+			err:   "`to` must contain `{name}` or `{path}`",
+		},
+		{
+			title: "to: not with prefix",
+			value: "path=/path/to/param,type=env,to=NAME,prefix=APP_",
+			err:   "can't use `to` with `prefix` in same time",
+		},
+		{
+			title: "join: requires to",
+			value: "path=/path/to/hosts/*,type=env,join=true",
+			err:   "`to` is required for `join`",
+		},
+		{
+			title: "join: not with expand",
+			value: "path=/path/to/hosts/*,type=env,to=HOSTS,join=true,expand=json",
+			err:   "`join` cannot be used with `expand`",
+		},
+		{
+			title: "join: not with list=index",
+			value: "path=/path/to/hosts/*,type=env,to=HOSTS,join=true,list=index",
+			err:   "`join` cannot be used with `list=index`",
+		},
+		{
+			title: "joinorder: invalid value",
+			value: "path=/path/to/hosts/*,type=env,to=HOSTS,join=true,joinorder=random",
+			err:   "invalid `joinorder`",
+		},
+		{
+			title: "joinsep: requires join",
+			value: "path=/path/to/hosts/*,type=env,joinsep=;",
+			err:   "`joinsep` requires `join`",
+		},
+		{
+			title: "join: only for `type=env`",
+			value: "path=/path/to/hosts/*,type=dir,to=/path/to/dir,join=true",
+			err:   "`join` is only allowed for `type=env`",
+		},
+		{
+			title: "decode: invalid value",
+			value: "path=/path/to/param,type=file,to=/path/to/file,decode=base32",
+			err:   "invalid `decode`",
+		},
+		{
+			title: "decode: not for `type=bundle`",
+			value: "path=/path/to/*,type=bundle,to=/path/to/file,format=json,decode=base64",
+			err:   "`decode` is only allowed for `type=env`, `type=file` or `type=dir`",
+		},
+		{
+			title: "mkdir: invalid value",
+			value: "path=/path/to/param,type=file,to=/path/to/file,mkdir=yes",
+			err:   "invalid `mkdir`",
+		},
 	}
 
 	for _, tt := range tests {
@@ -251,6 +667,40 @@ func TestRuleFlagsCheckOptionsCombinations(t *testing.T) {
 			},
 			err: "is only allowed for `type=file`",
 		},
+		{
+			title:    "cleanup: only for `type=file`",
+			destType: app.DestinationTypeEnv,
+			opts: map[string]string{
+				"cleanup": "true",
+			},
+			err: "is only allowed for `type=file`",
+		},
+		{
+			title:    "format: only for `type=bundle`",
+			destType: app.DestinationTypeFile,
+			opts: map[string]string{
+				"to":     "/path/to/file",
+				"format": "json",
+			},
+			err: "`format` is only allowed for `type=bundle`",
+		},
+		{
+			title:    "src: only for `type=template`",
+			destType: app.DestinationTypeBundle,
+			opts: map[string]string{
+				"to":  "/path/to/file",
+				"src": "/path/to/tmpl",
+			},
+			err: "`src` is only allowed for `type=template`",
+		},
+		{
+			title:    "prefix: only for `type=env` or `type=bundle`",
+			destType: app.DestinationTypeTemplate,
+			opts: map[string]string{
+				"prefix": "PREFIX_",
+			},
+			err: "`prefix` is only allowed for `type=env` or `type=bundle`",
+		},
 	}
 
 	for _, tt := range tests {
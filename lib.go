@@ -3,6 +3,7 @@ package ssmwrap
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/handlename/ssmwrap/v2/internal/app"
 )
@@ -30,12 +31,45 @@ type ExportRule struct {
 // Export fetches parameters from SSM and export those to environment variables.
 // This is for use ssmwrap as a library.
 func Export(ctx context.Context, ers []ExportRule, options ExportOptions) error {
+	env, err := export(ctx, ers, options)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range env {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set environment variable %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Environ fetches parameters from SSM and returns environment variables in form of `KEY=VALUE`,
+// which consist of environment variables of the current process and exported parameters.
+// Unlike Export, it does not change environment variables of the current process.
+// The result can be passed to exec.Cmd.Env.
+func Environ(ctx context.Context, ers []ExportRule, options ExportOptions) ([]string, error) {
+	exported, err := export(ctx, ers, options)
+	if err != nil {
+		return nil, err
+	}
+
+	env := app.NewEnv(os.Environ())
+	for name, value := range exported {
+		env[name] = value
+	}
+
+	return env.Environ(), nil
+}
+
+func export(ctx context.Context, ers []ExportRule, options ExportOptions) (app.Env, error) {
 	rules := make([]app.Rule, 0, len(ers))
 
 	for _, er := range ers {
 		pr, err := app.NewParameterRule(er.Path)
 		if err != nil {
-			return fmt.Errorf("failed to create ParameterRule: %w", err)
+			return nil, fmt.Errorf("failed to create ParameterRule: %w", err)
 		}
 
 		rules = append(rules, app.Rule{
@@ -55,9 +89,10 @@ func Export(ctx context.Context, ers []ExportRule, options ExportOptions) error
 		sw.Retries = options.Retries
 	}
 
-	if err := sw.Export(ctx, rules); err != nil {
-		return fmt.Errorf("failed to export parameters: %w", err)
+	exported, err := sw.Export(ctx, rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export parameters: %w", err)
 	}
 
-	return nil
+	return exported.Env, nil
 }